@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"social/internal/auth"
+	"social/internal/events"
 	"social/internal/mailer"
 	"social/internal/store"
 	"social/internal/store/cache"
@@ -32,6 +33,7 @@ type application struct {
 	logger        *zap.SugaredLogger
 	mailer        mailer.Client
 	authenticator auth.Authenticator
+	events        events.Publisher
 }
 
 type config struct {
@@ -43,6 +45,88 @@ type config struct {
 	frontendURL string
 	auth        authconfig
 	redisCfg    redisConfig
+	trending    trendingConfig
+	// maxPinnedPosts caps how many posts a user can pin to their profile.
+	maxPinnedPosts int
+	// pollFinalizeInterval is how often closed polls get their results frozen.
+	pollFinalizeInterval time.Duration
+	trash                trashConfig
+	// commentsPreviewSize is how many top comments come with a post; 0
+	// turns the preview off.
+	commentsPreviewSize int
+	suggestions         suggestionsConfig
+	followImports       followImportsConfig
+}
+
+// validate rejects settings the application can't run with.
+func (cfg config) validate() error {
+	intervals := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"TRENDING_INTERVAL", cfg.trending.interval},
+		{"POLL_FINALIZE_INTERVAL", cfg.pollFinalizeInterval},
+		{"TRASH_PURGE_INTERVAL", cfg.trash.purgeInterval},
+		{"SUGGESTIONS_INTERVAL", cfg.suggestions.interval},
+		{"FOLLOW_IMPORT_INTERVAL", cfg.followImports.interval},
+	}
+
+	for _, interval := range intervals {
+		if interval.value <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", interval.name, interval.value)
+		}
+	}
+
+	if _, ok := cfg.trending.windows[cfg.trending.defaultWindow]; !ok {
+		return fmt.Errorf("TRENDING_DEFAULT_WINDOW %q is not one of TRENDING_WINDOWS", cfg.trending.defaultWindow)
+	}
+
+	if cfg.commentsPreviewSize < 0 {
+		return fmt.Errorf("COMMENTS_PREVIEW_SIZE can't be negative, got %d", cfg.commentsPreviewSize)
+	}
+
+	if cfg.suggestions.perUser < 1 {
+		return fmt.Errorf("SUGGESTIONS_PER_USER must be at least 1, got %d", cfg.suggestions.perUser)
+	}
+
+	if cfg.followImports.batchSize < 1 {
+		return fmt.Errorf("FOLLOW_IMPORT_BATCH_SIZE must be at least 1, got %d", cfg.followImports.batchSize)
+	}
+
+	return nil
+}
+
+type followImportsConfig struct {
+	interval   time.Duration
+	batchSize  int
+	batchDelay time.Duration
+	// rateLimit caps the follows and follow requests an import can make for
+	// a user within rateLimitWindow.
+	rateLimit       int
+	rateLimitWindow time.Duration
+}
+
+type suggestionsConfig struct {
+	interval time.Duration
+	// activityWindow is how far back posts count as recent activity.
+	activityWindow time.Duration
+	// perUser is how many suggestions are precomputed for each user.
+	perUser int
+}
+
+type trashConfig struct {
+	// retention is how long deleted posts and comments stay restorable.
+	retention     time.Duration
+	purgeInterval time.Duration
+}
+
+type trendingConfig struct {
+	interval time.Duration
+	windows  map[string]time.Duration
+	// defaultWindow is the window served when a request names none; it has
+	// to be one of windows.
+	defaultWindow string
+	limit         int
 }
 
 type redisConfig struct {
@@ -121,23 +205,123 @@ func (app *application) mount() *chi.Mux {
 			// Posts routes
 			r.Route("/posts", func(r chi.Router) {
 				r.Post("/", app.createPostsHandler)
+				r.Post("/thread", app.createThreadHandler)
 
 				r.Route("/{id}", func(r chi.Router) {
 					r.Get("/", app.getPostHandler)
 					r.Delete("/", app.checkPostOwnership("admin", app.deletePostHandler))
 					r.Patch("/", app.checkPostOwnership("moderator", app.updatePostHandler))
 					r.Post("/comment", app.checkPostOwnership("user", app.createCommentHandler))
+
+					r.Post("/restore", app.restorePostHandler)
+
+					r.Route("/comments/{commentID}", func(r chi.Router) {
+						r.Post("/restore", app.restoreCommentHandler)
+
+						r.Group(func(r chi.Router) {
+							r.Use(app.commentsContextMiddleware)
+
+							r.Patch("/", app.checkCommentOwnership("moderator", app.updateCommentHandler))
+							r.Delete("/", app.checkCommentOwnership("moderator", app.deleteCommentHandler))
+							r.Put("/hide", app.checkPostOwnership("moderator", app.hideCommentHandler))
+							r.Delete("/hide", app.checkPostOwnership("moderator", app.unhideCommentHandler))
+							r.Post("/approve", app.checkPostOwnership("moderator", app.approveCommentHandler))
+							r.Post("/reject", app.checkPostOwnership("moderator", app.rejectCommentHandler))
+						})
+					})
+
+					r.Group(func(r chi.Router) {
+						r.Use(app.postsContextMiddleware)
+
+						r.Get("/thread", app.getThreadHandler)
+						r.Get("/comments", app.getCommentsHandler)
+						r.Get("/comments/{commentID}/replies", app.getCommentRepliesHandler)
+						r.Get("/comments/pending", app.checkPostOwnership("moderator", app.getPendingCommentsHandler))
+
+						r.Get("/reactions", app.getPostReactionsHandler)
+						r.Put("/reactions/{kind}", app.reactToPostHandler)
+						r.Delete("/reactions/{kind}", app.unreactToPostHandler)
+
+						r.Put("/repost", app.repostHandler)
+						r.Delete("/repost", app.unrepostHandler)
+
+						r.Put("/bookmark", app.bookmarkPostHandler)
+						r.Delete("/bookmark", app.unbookmarkPostHandler)
+
+						r.Put("/pin", app.checkPostOwnership("moderator", app.pinPostHandler))
+						r.Delete("/pin", app.checkPostOwnership("moderator", app.unpinPostHandler))
+
+						r.Post("/poll/votes", app.votePollHandler)
+					})
 				})
 			})
 
+			// Tag routes
+			r.Route("/tags", func(r chi.Router) {
+				r.Get("/", app.searchTagsHandler)
+				r.Get("/{name}/posts", app.getTagPostsHandler)
+			})
+
+			// Trending routes
+			r.Route("/trending", func(r chi.Router) {
+				r.Get("/tags", app.getTrendingTagsHandler)
+				r.Get("/posts", app.getTrendingPostsHandler)
+			})
+
 			// User routes
 			r.Route("/users", func(r chi.Router) {
 				r.Get("/feed", app.getUserFeedHandler)
+				r.Get("/me/mentions", app.getUserMentionsHandler)
+				r.Put("/me/privacy", app.updatePrivacyHandler)
+				r.Get("/me/blocks", app.getBlockedUsersHandler)
+				r.Get("/me/mutes", app.getMutedUsersHandler)
+				r.Get("/me/suggestions", app.getSuggestionsHandler)
+				r.Get("/me/followers/export", app.exportFollowersHandler)
+
+				r.Route("/me/lists", func(r chi.Router) {
+					r.Get("/", app.getAudienceListsHandler)
+					r.Post("/", app.createAudienceListHandler)
+					r.Patch("/{listID}", app.updateAudienceListHandler)
+					r.Delete("/{listID}", app.deleteAudienceListHandler)
+					r.Get("/{listID}/members", app.getAudienceListMembersHandler)
+					r.Put("/{listID}/members/{userID}", app.addAudienceListMemberHandler)
+					r.Delete("/{listID}/members/{userID}", app.removeAudienceListMemberHandler)
+				})
+
+				r.Route("/me/following", func(r chi.Router) {
+					r.Get("/export", app.exportFollowingHandler)
+					r.Post("/import", app.importFollowsHandler)
+					r.Get("/imports/{importID}", app.getFollowImportHandler)
+				})
+
+				r.Route("/me/follow-requests", func(r chi.Router) {
+					r.Get("/", app.getFollowRequestsHandler)
+					r.Post("/{requesterID}/approve", app.approveFollowRequestHandler)
+					r.Delete("/{requesterID}", app.rejectFollowRequestHandler)
+				})
+
+				r.Route("/me/bookmarks", func(r chi.Router) {
+					r.Get("/", app.getBookmarksHandler)
+					r.Get("/collections", app.getBookmarkCollectionsHandler)
+					r.Post("/collections", app.createBookmarkCollectionHandler)
+					r.Patch("/collections/{collectionID}", app.updateBookmarkCollectionHandler)
+					r.Delete("/collections/{collectionID}", app.deleteBookmarkCollectionHandler)
+				})
 
 				r.Route("/{userID}", func(r chi.Router) {
 					r.Get("/", app.getUserHandler)
 					r.Put("/follow", app.followUserHandler)
 					r.Put("/unfollow", app.unfollowUserHandler)
+					r.Delete("/follow-request", app.cancelFollowRequestHandler)
+					r.Put("/block", app.blockUserHandler)
+					r.Delete("/block", app.unblockUserHandler)
+					r.Put("/mute", app.muteUserHandler)
+					r.Delete("/mute", app.unmuteUserHandler)
+					r.Get("/followers", app.getFollowersHandler)
+					r.Get("/following", app.getFollowingHandler)
+					r.Get("/posts", app.getUserPostsHandler)
+					r.Put("/pins", app.reorderPinsHandler)
+					r.Get("/trash", app.getTrashHandler)
 				})
 			})
 		})
@@ -162,6 +346,11 @@ func (app *application) run(mux *chi.Mux) error {
 
 	shutdown := make(chan error)
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	app.startJobs(jobsCtx)
+
 	go func() {
 		quit := make(chan os.Signal, 1)
 
@@ -173,6 +362,8 @@ func (app *application) run(mux *chi.Mux) error {
 
 		app.logger.Infow("signal caught", "signal", s.String())
 
+		stopJobs()
+
 		shutdown <- srv.Shutdown(ctx)
 	}()
 
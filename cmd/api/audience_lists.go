@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"social/internal/store"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type audienceListPayload struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// getAudienceListsHandler godoc
+//
+//	@Summary		Fetches the user's audience lists
+//	@Description	Fetches the lists the authenticated user can share posts with, with their sizes
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	[]store.AudienceList
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/lists [get]
+func (app *application) getAudienceListsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	lists, err := app.store.AudienceLists.GetByUserID(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, lists); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// createAudienceListHandler godoc
+//
+//	@Summary		Creates an audience list
+//	@Description	Creates a named list of users, such as close friends, that posts can be shared with. Only its owner can see it.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		audienceListPayload	true	"List payload"
+//	@Success		201		{object}	store.AudienceList
+//	@Failure		400		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/lists [post]
+func (app *application) createAudienceListHandler(w http.ResponseWriter, r *http.Request) {
+	var payload audienceListPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	list := &store.AudienceList{
+		UserID: user.ID,
+		Name:   payload.Name,
+	}
+
+	if err := app.store.AudienceLists.Create(ctx, list); err != nil {
+		switch {
+		case errors.Is(err, store.ErrDuplicateAudienceList):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, list); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// updateAudienceListHandler godoc
+//
+//	@Summary		Renames an audience list
+//	@Description	Renames one of the user's audience lists
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			listID	path		int					true	"List ID"
+//	@Param			payload	body		audienceListPayload	true	"List payload"
+//	@Success		200		{object}	store.AudienceList
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/lists/{listID} [patch]
+func (app *application) updateAudienceListHandler(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.ParseInt(chi.URLParam(r, "listID"), 10, 64)
+	if err != nil {
+		app.badRequestError(w, r, "invalid list id")
+		return
+	}
+
+	var payload audienceListPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	list := &store.AudienceList{
+		ID:     listID,
+		UserID: user.ID,
+		Name:   payload.Name,
+	}
+
+	if err := app.store.AudienceLists.Rename(ctx, list); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		case errors.Is(err, store.ErrDuplicateAudienceList):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	list, err = app.store.AudienceLists.GetByID(ctx, listID, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, list); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// deleteAudienceListHandler godoc
+//
+//	@Summary		Deletes an audience list
+//	@Description	Deletes a list. Posts shared with it stay visible to their author only.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			listID	path		int	true	"List ID"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/lists/{listID} [delete]
+func (app *application) deleteAudienceListHandler(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.ParseInt(chi.URLParam(r, "listID"), 10, 64)
+	if err != nil {
+		app.badRequestError(w, r, "invalid list id")
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := app.store.AudienceLists.Delete(ctx, listID, user.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getAudienceListMembersHandler godoc
+//
+//	@Summary		Lists an audience list's members
+//	@Description	Lists the members of one of the user's audience lists, most recently added first
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			listID	path		int		true	"List ID"
+//	@Param			cursor	query		string	false	"Cursor from a previous page"
+//	@Param			limit	query		int		false	"Limit"
+//	@Success		200		{object}	relatedUsersPage
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/lists/{listID}/members [get]
+func (app *application) getAudienceListMembersHandler(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.loadAudienceList(w, r)
+	if !ok {
+		return
+	}
+
+	app.writeRelatedUsersPage(w, r, func(ctx context.Context, _ int64, cursor *store.Cursor, limit int) ([]store.RelatedUser, error) {
+		return app.store.AudienceLists.GetMembers(ctx, list.ID, cursor, limit)
+	})
+}
+
+// addAudienceListMemberHandler godoc
+//
+//	@Summary		Adds a user to an audience list
+//	@Description	Adds a user to one of the user's audience lists. The member is not told.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			listID	path		int	true	"List ID"
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/lists/{listID}/members/{userID} [put]
+func (app *application) addAudienceListMemberHandler(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.loadAudienceList(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil || memberID < 1 {
+		app.badRequestError(w, r, "invalid user id")
+		return
+	}
+
+	if int64(memberID) == list.UserID {
+		app.badRequestError(w, r, "you can't add yourself to your own list")
+		return
+	}
+
+	ctx := r.Context()
+
+	if _, err := app.store.Users.GetById(ctx, memberID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	if err := app.store.AudienceLists.AddMember(ctx, list.ID, int64(memberID)); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeAudienceListMemberHandler godoc
+//
+//	@Summary		Removes a user from an audience list
+//	@Description	Removes a user from one of the user's audience lists; they lose access to the posts shared with it
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			listID	path		int	true	"List ID"
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/lists/{listID}/members/{userID} [delete]
+func (app *application) removeAudienceListMemberHandler(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.loadAudienceList(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil || memberID < 1 {
+		app.badRequestError(w, r, "invalid user id")
+		return
+	}
+
+	if err := app.store.AudienceLists.RemoveMember(r.Context(), list.ID, memberID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, "user is not on this list")
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadAudienceList fetches the list in the URL, which must belong to the
+// authenticated user. It writes the error response itself and reports
+// whether the caller may go on.
+func (app *application) loadAudienceList(w http.ResponseWriter, r *http.Request) (*store.AudienceList, bool) {
+	listID, err := strconv.ParseInt(chi.URLParam(r, "listID"), 10, 64)
+	if err != nil {
+		app.badRequestError(w, r, "invalid list id")
+		return nil, false
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return nil, false
+	}
+
+	list, err := app.store.AudienceLists.GetByID(ctx, listID, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return nil, false
+	}
+
+	return list, true
+}
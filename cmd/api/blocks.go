@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"social/internal/store"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type relatedUsersPage struct {
+	Users      []store.RelatedUser `json:"users"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// blockUserHandler godoc
+//
+//	@Summary		Blocks a user
+//	@Description	Blocks a user. Blocked users and their blocker can't see each other's profiles, posts or comments, follow, comment on or mention each other. Existing follows between them are removed.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/block [put]
+func (app *application) blockUserHandler(w http.ResponseWriter, r *http.Request) {
+	app.addRelatedUser(w, r, "block", app.store.Blocks.Block)
+}
+
+// unblockUserHandler godoc
+//
+//	@Summary		Unblocks a user
+//	@Description	Lifts a block. Follows removed by the block are not restored.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/block [delete]
+func (app *application) unblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	app.removeRelatedUser(w, r, "block", app.store.Blocks.Unblock)
+}
+
+// getBlockedUsersHandler godoc
+//
+//	@Summary		Lists blocked users
+//	@Description	Lists the users the authenticated user has blocked, most recent first
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			cursor	query		string	false	"Cursor from a previous page"
+//	@Param			limit	query		int		false	"Limit"
+//	@Success		200		{object}	relatedUsersPage
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/blocks [get]
+func (app *application) getBlockedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	app.writeRelatedUsersPage(w, r, app.store.Blocks.GetBlocked)
+}
+
+// muteUserHandler godoc
+//
+//	@Summary		Mutes a user
+//	@Description	Hides a user's posts and comments from the authenticated user's feed and comment listings. The muted user is not told.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/mute [put]
+func (app *application) muteUserHandler(w http.ResponseWriter, r *http.Request) {
+	app.addRelatedUser(w, r, "mute", app.store.Mutes.Mute)
+}
+
+// unmuteUserHandler godoc
+//
+//	@Summary		Unmutes a user
+//	@Description	Shows a muted user's posts and comments again
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/mute [delete]
+func (app *application) unmuteUserHandler(w http.ResponseWriter, r *http.Request) {
+	app.removeRelatedUser(w, r, "mute", app.store.Mutes.Unmute)
+}
+
+// getMutedUsersHandler godoc
+//
+//	@Summary		Lists muted users
+//	@Description	Lists the users the authenticated user has muted, most recent first
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			cursor	query		string	false	"Cursor from a previous page"
+//	@Param			limit	query		int		false	"Limit"
+//	@Success		200		{object}	relatedUsersPage
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/mutes [get]
+func (app *application) getMutedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	app.writeRelatedUsersPage(w, r, app.store.Mutes.GetMuted)
+}
+
+// addRelatedUser adds the user in the URL to one of the authenticated
+// user's lists. verb names the action in error messages.
+func (app *application) addRelatedUser(w http.ResponseWriter, r *http.Request, verb string, add func(ctx context.Context, userID, otherID int64) error) {
+	otherID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil || otherID < 1 {
+		app.badRequestError(w, r, "invalid user id")
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if user.ID == int64(otherID) {
+		app.badRequestError(w, r, "you can't "+verb+" yourself")
+		return
+	}
+
+	if _, err := app.store.Users.GetById(ctx, otherID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	if err := add(ctx, user.ID, int64(otherID)); err != nil {
+		switch {
+		case errors.Is(err, store.ErrAlreadyBlocked), errors.Is(err, store.ErrAlreadyMuted):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeRelatedUser removes the user in the URL from one of the
+// authenticated user's lists. verb names the action in error messages.
+func (app *application) removeRelatedUser(w http.ResponseWriter, r *http.Request, verb string, remove func(ctx context.Context, userID, otherID int64) error) {
+	otherID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil || otherID < 1 {
+		app.badRequestError(w, r, "invalid user id")
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := remove(ctx, user.ID, otherID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, "no "+verb+" found for this user")
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeRelatedUsersPage writes a page of one of the authenticated user's
+// lists, as returned by list.
+func (app *application) writeRelatedUsersPage(w http.ResponseWriter, r *http.Request, list func(ctx context.Context, userID int64, cursor *store.Cursor, limit int) ([]store.RelatedUser, error)) {
+	qs := r.URL.Query()
+
+	limit := 20
+
+	if l := qs.Get("limit"); l != "" {
+		var err error
+
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > 100 {
+			app.badRequestError(w, r, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	var cursor *store.Cursor
+
+	if c := qs.Get("cursor"); c != "" {
+		decoded, err := store.DecodeCursor(c)
+		if err != nil {
+			app.badRequestError(w, r, err.Error())
+			return
+		}
+		cursor = &decoded
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	page := relatedUsersPage{}
+
+	page.Users, err = list(ctx, user.ID, cursor, limit)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if len(page.Users) == limit {
+		last := page.Users[len(page.Users)-1]
+
+		next, err := store.NewCursor(last.CreatedAt, last.User.ID)
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		page.NextCursor = next.Encode()
+	}
+
+	if err := writeJSON(w, http.StatusOK, page); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// hiddenByBlock reports whether a block between the viewer and userID, in
+// either direction, hides userID's profile from the viewer.
+func (app *application) hiddenByBlock(ctx context.Context, viewerID, userID int64) (bool, error) {
+	if viewerID == userID {
+		return false, nil
+	}
+
+	return app.store.Blocks.IsBlocked(ctx, viewerID, userID)
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"social/internal/store"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type bookmarkPostPayload struct {
+	CollectionID *int64 `json:"collection_id" validate:"omitempty,gte=1"`
+}
+
+// bookmarkPostHandler godoc
+//
+//	@Summary		Bookmarks a post
+//	@Description	Bookmarks a post, optionally into one of the user's collections. Bookmarking again moves it.
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Post ID"
+//	@Param			payload	body		bookmarkPostPayload	false	"Bookmark payload"
+//	@Success		200		{object}	store.Bookmark
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/bookmark [put]
+func (app *application) bookmarkPostHandler(w http.ResponseWriter, r *http.Request) {
+	var payload bookmarkPostPayload
+
+	// The body is optional: an empty one bookmarks without a collection.
+	if err := readJSON(w, r, &payload); err != nil && !errors.Is(err, io.EOF) {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if payload.CollectionID != nil {
+		if _, err := app.store.Bookmarks.GetCollection(ctx, *payload.CollectionID, user.ID); err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.badRequestError(w, r, "collection not found")
+			default:
+				app.internalServerError(w, r, err.Error())
+			}
+			return
+		}
+	}
+
+	bookmark := &store.Bookmark{
+		UserID:       user.ID,
+		PostID:       int64(post.ID),
+		CollectionID: payload.CollectionID,
+	}
+
+	if err := app.store.Bookmarks.Save(ctx, bookmark); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, bookmark); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// unbookmarkPostHandler godoc
+//
+//	@Summary		Removes a bookmark
+//	@Description	Removes the user's bookmark on a post. Removing a missing bookmark is a no-op.
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"Post ID"
+//	@Success		204	{object}	string
+//	@Failure		400	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/bookmark [delete]
+func (app *application) unbookmarkPostHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := app.store.Bookmarks.Remove(ctx, user.ID, int64(post.ID)); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getBookmarksHandler godoc
+//
+//	@Summary		Fetches the user's bookmarks
+//	@Description	Fetches the user's bookmarked posts, most recently bookmarked first by default
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			collection_id	query		int		false	"Collection ID"
+//	@Param			limit			query		int		false	"Limit"
+//	@Param			offset			query		int		false	"Offset"
+//	@Param			sort			query		string	false	"Sort"
+//	@Param			tags			query		string	false	"Tags"
+//	@Param			search			query		string	false	"Search"
+//	@Success		200				{object}	[]store.BookmarkedPost
+//	@Failure		400				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks [get]
+func (app *application) getBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	fq := store.PaginatedFieldQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "desc",
+	}
+
+	if err := fq.Parse(r); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	var collectionID *int64
+
+	if c := r.URL.Query().Get("collection_id"); c != "" {
+		id, err := strconv.ParseInt(c, 10, 64)
+		if err != nil {
+			app.badRequestError(w, r, "invalid collection id")
+			return
+		}
+		collectionID = &id
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	posts, err := app.store.Bookmarks.GetByUserID(ctx, user.ID, collectionID, fq)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	for i := range posts {
+		if err := app.renderPostContent(ctx, &posts[i].Post); err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+	}
+
+	if err := writeJSON(w, http.StatusOK, posts); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+type bookmarkCollectionPayload struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// getBookmarkCollectionsHandler godoc
+//
+//	@Summary		Fetches the user's bookmark collections
+//	@Description	Fetches the user's bookmark collections with their sizes
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	[]store.BookmarkCollection
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks/collections [get]
+func (app *application) getBookmarkCollectionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	collections, err := app.store.Bookmarks.GetCollections(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, collections); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// createBookmarkCollectionHandler godoc
+//
+//	@Summary		Creates a bookmark collection
+//	@Description	Creates a named bookmark collection
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		bookmarkCollectionPayload	true	"Collection payload"
+//	@Success		201		{object}	store.BookmarkCollection
+//	@Failure		400		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks/collections [post]
+func (app *application) createBookmarkCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	var payload bookmarkCollectionPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	collection := &store.BookmarkCollection{
+		UserID: user.ID,
+		Name:   payload.Name,
+	}
+
+	if err := app.store.Bookmarks.CreateCollection(ctx, collection); err != nil {
+		switch {
+		case errors.Is(err, store.ErrDuplicateCollection):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, collection); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// updateBookmarkCollectionHandler godoc
+//
+//	@Summary		Renames a bookmark collection
+//	@Description	Renames one of the user's bookmark collections
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			collectionID	path		int							true	"Collection ID"
+//	@Param			payload			body		bookmarkCollectionPayload	true	"Collection payload"
+//	@Success		200				{object}	store.BookmarkCollection
+//	@Failure		400				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		409				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks/collections/{collectionID} [patch]
+func (app *application) updateBookmarkCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := strconv.ParseInt(chi.URLParam(r, "collectionID"), 10, 64)
+	if err != nil {
+		app.badRequestError(w, r, "invalid collection id")
+		return
+	}
+
+	var payload bookmarkCollectionPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	collection := &store.BookmarkCollection{
+		ID:     collectionID,
+		UserID: user.ID,
+		Name:   payload.Name,
+	}
+
+	if err := app.store.Bookmarks.RenameCollection(ctx, collection); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		case errors.Is(err, store.ErrDuplicateCollection):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	collection, err = app.store.Bookmarks.GetCollection(ctx, collectionID, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, collection); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// deleteBookmarkCollectionHandler godoc
+//
+//	@Summary		Deletes a bookmark collection
+//	@Description	Deletes a collection; its bookmarks are kept without a collection
+//	@Tags			bookmarks
+//	@Accept			json
+//	@Produce		json
+//	@Param			collectionID	path		int	true	"Collection ID"
+//	@Success		204				{object}	string
+//	@Failure		400				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/bookmarks/collections/{collectionID} [delete]
+func (app *application) deleteBookmarkCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	collectionID, err := strconv.ParseInt(chi.URLParam(r, "collectionID"), 10, 64)
+	if err != nil {
+		app.badRequestError(w, r, "invalid collection id")
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := app.store.Bookmarks.DeleteCollection(ctx, collectionID, user.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
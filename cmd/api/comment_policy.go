@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"social/internal/store"
+)
+
+var errCommentsNotAllowed = errors.New("comments not allowed")
+
+// commentStatus applies the post's comment policy to a new comment by
+// userID. It returns the status the comment starts in, or an error wrapping
+// errCommentsNotAllowed when the user may not comment. The post's author can
+// always comment; users sharing a block with the author never can.
+func (app *application) commentStatus(ctx context.Context, post *store.Post, userID int64) (string, error) {
+	if post.UserId == userID {
+		return store.CommentStatusApproved, nil
+	}
+
+	blocked, err := app.store.Blocks.IsBlocked(ctx, userID, post.UserId)
+	if err != nil {
+		return "", err
+	}
+
+	if blocked {
+		return "", fmt.Errorf("%w: you can't comment on this user's posts", errCommentsNotAllowed)
+	}
+
+	switch post.CommentPolicy {
+	case store.CommentPolicyNobody:
+		return "", fmt.Errorf("%w: comments are turned off for this post", errCommentsNotAllowed)
+
+	case store.CommentPolicyFollowers:
+		following, err := app.store.Followers.IsFollowing(ctx, userID, post.UserId)
+		if err != nil {
+			return "", err
+		}
+
+		if !following {
+			return "", fmt.Errorf("%w: only followers of the author can comment", errCommentsNotAllowed)
+		}
+
+	case store.CommentPolicyMentioned:
+		mentions, err := app.store.Mentions.GetByPostID(ctx, int64(post.ID))
+		if err != nil {
+			return "", err
+		}
+
+		mentioned := false
+		for _, m := range mentions {
+			if m.CommentID == nil && m.UserID == userID {
+				mentioned = true
+				break
+			}
+		}
+
+		if !mentioned {
+			return "", fmt.Errorf("%w: only users mentioned in the post can comment", errCommentsNotAllowed)
+		}
+
+	case store.CommentPolicyApproval:
+		return store.CommentStatusPending, nil
+	}
+
+	return store.CommentStatusApproved, nil
+}
+
+// getPendingCommentsHandler godoc
+//
+//	@Summary		Fetches a post's approval queue
+//	@Description	Lists the comments waiting for approval on a post, oldest first. Only the post's author or a moderator can see them.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int	true	"Post ID"
+//	@Param			limit	query		int	false	"Limit"
+//	@Param			offset	query		int	false	"Offset"
+//	@Success		200		{object}	[]store.Comment
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/pending [get]
+func (app *application) getPendingCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	fq := store.PaginatedFieldQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "asc",
+	}
+
+	if err := fq.Parse(r); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	comments, err := app.store.Comments.GetPending(ctx, post.ID, fq)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, comments); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// approveCommentHandler godoc
+//
+//	@Summary		Approves a comment
+//	@Description	Publishes a comment held for approval. Only the post's author or a moderator can approve it.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int	true	"Post ID"
+//	@Param			commentID	path		int	true	"Comment ID"
+//	@Success		200			{object}	store.Comment
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentID}/approve [post]
+func (app *application) approveCommentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	comment, err := getCommentFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if comment.Status != store.CommentStatusPending {
+		writeJSONError(w, http.StatusConflict, "comment is not pending")
+		return
+	}
+
+	comment.Mentions = mentionCandidates(comment.Content)
+
+	if err := app.store.Comments.Approve(ctx, comment); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	commentID := int64(comment.ID)
+
+	app.notifyMentions(ctx, int64(comment.PostID), &commentID, int64(comment.UserID), comment.Mentions, nil)
+
+	if err := writeJSON(w, http.StatusOK, comment); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// rejectCommentHandler godoc
+//
+//	@Summary		Rejects a comment
+//	@Description	Discards a comment held for approval; it goes to its author's trash. Only the post's author or a moderator can reject it.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path	int	true	"Post ID"
+//	@Param			commentID	path	int	true	"Comment ID"
+//	@Success		204			"Comment rejected"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentID}/reject [post]
+func (app *application) rejectCommentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	comment, err := getCommentFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if comment.Status != store.CommentStatusPending {
+		writeJSONError(w, http.StatusConflict, "comment is not pending")
+		return
+	}
+
+	if err := app.store.Comments.Delete(ctx, comment.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
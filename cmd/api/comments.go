@@ -0,0 +1,426 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"social/internal/store"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type commentContextKey string
+
+const commentContext commentContextKey = "comment"
+
+const (
+	// defaultCommentDepth is how many levels of a comment tree are loaded
+	// unless the client asks for more or fewer.
+	defaultCommentDepth = 3
+	maxCommentDepth     = 10
+	// defaultRepliesLimit caps the replies loaded under each comment;
+	// the rest are fetched through the replies endpoint.
+	defaultRepliesLimit = 5
+)
+
+type updateCommentPayload struct {
+	Content string `json:"content" validate:"required,max=1000"`
+}
+
+// updateCommentHandler godoc
+//
+//	@Summary		Edits a comment
+//	@Description	Replaces a comment's content. Only its author or a moderator can edit it.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int						true	"Post ID"
+//	@Param			commentID	path		int						true	"Comment ID"
+//	@Param			payload		body		updateCommentPayload	true	"Comment payload"
+//	@Success		200			{object}	store.Comment
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentID} [patch]
+func (app *application) updateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	var payload updateCommentPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	comment, err := getCommentFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	previous, err := app.store.Mentions.GetByPostID(ctx, int64(comment.PostID))
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	_, commented := splitMentions(previous, []store.Comment{*comment})
+
+	comment.Content = payload.Content
+	comment.Mentions = mentionCandidates(comment.Content)
+
+	if err := app.store.Comments.Update(ctx, comment); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	commentID := int64(comment.ID)
+
+	app.notifyMentions(ctx, int64(comment.PostID), &commentID, int64(comment.UserID), comment.Mentions, commented[0].Mentions)
+
+	if err := writeJSON(w, http.StatusOK, comment); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// deleteCommentHandler godoc
+//
+//	@Summary		Deletes a comment
+//	@Description	Moves a comment to its author's trash. Only its author or a moderator can delete it.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path	int	true	"Post ID"
+//	@Param			commentID	path	int	true	"Comment ID"
+//	@Success		204			"Comment deleted"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentID} [delete]
+func (app *application) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	comment, err := getCommentFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := app.store.Comments.Delete(ctx, comment.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// hideCommentHandler godoc
+//
+//	@Summary		Hides a comment
+//	@Description	Hides a comment from everyone but its author. Only the post's author or a moderator can hide it.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path	int	true	"Post ID"
+//	@Param			commentID	path	int	true	"Comment ID"
+//	@Success		204			"Comment hidden"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentID}/hide [put]
+func (app *application) hideCommentHandler(w http.ResponseWriter, r *http.Request) {
+	app.setCommentHidden(w, r, true)
+}
+
+// unhideCommentHandler godoc
+//
+//	@Summary		Unhides a comment
+//	@Description	Makes a hidden comment visible again. Only the post's author or a moderator can unhide it.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path	int	true	"Post ID"
+//	@Param			commentID	path	int	true	"Comment ID"
+//	@Success		204			"Comment visible"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentID}/hide [delete]
+func (app *application) unhideCommentHandler(w http.ResponseWriter, r *http.Request) {
+	app.setCommentHidden(w, r, false)
+}
+
+func (app *application) setCommentHidden(w http.ResponseWriter, r *http.Request, hidden bool) {
+	ctx := r.Context()
+
+	comment, err := getCommentFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := app.store.Comments.SetHidden(ctx, comment.ID, hidden); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type commentsPage struct {
+	Comments   []store.Comment `json:"comments"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// getCommentsHandler godoc
+//
+//	@Summary		Fetches a post's comments
+//	@Description	Lists a post's top-level comments a page at a time, each with its replies down to the requested depth
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int		true	"Post ID"
+//	@Param			sort	query		string	false	"newest (default), oldest or top"
+//	@Param			cursor	query		string	false	"next_cursor from a previous page"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			depth	query		int		false	"Levels of comments to load"
+//	@Success		200		{object}	commentsPage
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments [get]
+func (app *application) getCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	post, err := getPostFromContext(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	q, err := parseCommentTreeQuery(r, store.CommentsSortNewest)
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	app.writeCommentsPage(w, r, post.ID, q)
+}
+
+// getCommentRepliesHandler godoc
+//
+//	@Summary		Fetches replies to a comment
+//	@Description	Loads more replies to a comment, each with its own replies down to the requested depth
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int		true	"Post ID"
+//	@Param			commentID	path		int		true	"Comment ID"
+//	@Param			sort		query		string	false	"oldest (default), newest or top"
+//	@Param			cursor		query		string	false	"replies_cursor from the parent comment or next_cursor from a previous page"
+//	@Param			limit		query		int		false	"Limit"
+//	@Param			depth		query		int		false	"Levels of replies to load"
+//	@Success		200			{object}	commentsPage
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentID}/replies [get]
+func (app *application) getCommentRepliesHandler(w http.ResponseWriter, r *http.Request) {
+	post, err := getPostFromContext(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	commentID, err := strconv.ParseInt(chi.URLParam(r, "commentID"), 10, 64)
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	q, err := parseCommentTreeQuery(r, store.CommentsSortOldest)
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	q.ParentID = &commentID
+
+	app.writeCommentsPage(w, r, post.ID, q)
+}
+
+// writeCommentsPage loads a page of the post's comment tree for the current
+// user and writes it along with the cursor to the next page.
+func (app *application) writeCommentsPage(w http.ResponseWriter, r *http.Request, postID int, q store.CommentTreeQuery) {
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	page := commentsPage{}
+
+	page.Comments, err = app.store.Comments.GetByPostID(ctx, postID, user.ID, q)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if len(page.Comments) == q.Limit {
+		next, err := store.CommentCursor(page.Comments[len(page.Comments)-1], q.Sort)
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		page.NextCursor = next.Encode()
+	}
+
+	mentions, err := app.store.Mentions.GetByPostID(ctx, int64(postID))
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	_, page.Comments = splitMentions(mentions, page.Comments)
+
+	if err := writeJSON(w, http.StatusOK, page); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// parseCommentTreeQuery reads the sort, cursor, limit and depth query
+// parameters of a comment listing.
+func parseCommentTreeQuery(r *http.Request, defaultSort string) (store.CommentTreeQuery, error) {
+	qs := r.URL.Query()
+
+	q := store.CommentTreeQuery{
+		Sort:         defaultSort,
+		Limit:        20,
+		RepliesLimit: defaultRepliesLimit,
+	}
+
+	if sort := qs.Get("sort"); sort != "" {
+		switch sort {
+		case store.CommentsSortNewest, store.CommentsSortOldest, store.CommentsSortTop:
+			q.Sort = sort
+		default:
+			return q, errors.New("sort must be one of newest, oldest or top")
+		}
+	}
+
+	if l := qs.Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > 100 {
+			return q, errors.New("limit must be between 1 and 100")
+		}
+		q.Limit = limit
+	}
+
+	if c := qs.Get("cursor"); c != "" {
+		cursor, err := store.DecodeCursor(c)
+		if err != nil {
+			return q, err
+		}
+		q.Cursor = &cursor
+	}
+
+	depth, err := parseCommentDepth(r)
+	if err != nil {
+		return q, err
+	}
+
+	q.Depth = depth
+
+	return q, nil
+}
+
+// parseCommentDepth reads the depth query parameter, which selects how many
+// levels of a comment tree to load.
+func parseCommentDepth(r *http.Request) (int, error) {
+	d := r.URL.Query().Get("depth")
+	if d == "" {
+		return defaultCommentDepth, nil
+	}
+
+	depth, err := strconv.Atoi(d)
+	if err != nil || depth < 1 || depth > maxCommentDepth {
+		return 0, fmt.Errorf("depth must be between 1 and %d", maxCommentDepth)
+	}
+
+	return depth, nil
+}
+
+// commentsContextMiddleware loads the comment from the {commentID} URL
+// parameter into the request context, making sure it belongs to the post in
+// {id}.
+func (app *application) commentsContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		postID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			app.badRequestError(w, r, err.Error())
+			return
+		}
+
+		commentID, err := strconv.Atoi(chi.URLParam(r, "commentID"))
+		if err != nil {
+			app.badRequestError(w, r, err.Error())
+			return
+		}
+
+		comment, err := app.store.Comments.GetByID(ctx, commentID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		if comment == nil || comment.PostID != postID {
+			app.notFoundError(w, r, store.ErrNotFound.Error())
+			return
+		}
+
+		ctx = context.WithValue(ctx, commentContext, comment)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getCommentFromContext(ctx context.Context) (*store.Comment, error) {
+	comment, ok := ctx.Value(commentContext).(*store.Comment)
+	if !ok {
+		return nil, errors.New("comment not found in context")
+	}
+	return comment, nil
+}
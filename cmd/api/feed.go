@@ -1,26 +1,38 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"social/internal/store"
+	"strings"
 )
 
+// feedPage is the body of a feed response. It replaced the bare array of
+// posts the feed used to return.
+type feedPage struct {
+	Posts      []store.PostWithMetadata `json:"posts"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+	PrevCursor string                   `json:"prev_cursor,omitempty"`
+}
+
 // getUserFeedHandler godoc
 //
 //	@Summary		Fetches the user feed
-//	@Description	Fetches the user feed
+//	@Description	Fetches the user feed. A post appears once, attributed to whoever brought it into the feed first. Pages are walked with the opaque next_cursor and prev_cursor, also sent as Link headers; offset is still accepted but can't be combined with a cursor. Breaking change: the body is now an object whose posts field holds the page, where it used to be a bare array of posts; clients reading the array must read posts instead.
 //	@Tags			feed
 //	@Accept			json
 //	@Produce		json
-//	@Param			since	query		string	false	"Since"
-//	@Param			until	query		string	false	"Until"
+//	@Param			since	query		string	false	"Only items entering the feed at or after this RFC 3339 time"
+//	@Param			until	query		string	false	"Only items entering the feed at or before this RFC 3339 time"
 //	@Param			limit	query		int		false	"Limit"
+//	@Param			cursor	query		string	false	"next_cursor or prev_cursor from a previous page"
 //	@Param			offset	query		int		false	"Offset"
-//	@Param			sort	query		string	false	"Sort"
+//	@Param			sort	query		string	false	"newest (default), oldest, most_commented or most_reacted"
 //	@Param			tags	query		string	false	"Tags"
 //	@Param			search	query		string	false	"Search"
-//	@Success		200		{object}	[]store.PostWithMetadata
+//	@Success		200		{object}	feedPage
 //	@Failure		400		{object}	error
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
@@ -30,7 +42,7 @@ func(app *application) getUserFeedHandler(w http.ResponseWriter, r *http.Request
 	fq := store.PaginatedFieldQuery{
 		Limit: 5,
 		Offset: 0,
-		Sort: "desc",
+		Sort: store.FeedSortNewest,
 	}
 
 	if err := fq.Parse(r); err != nil {
@@ -38,14 +50,42 @@ func(app *application) getUserFeedHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	fmt.Printf("Feed query: limit=%d offset=%d search=%s tags=%v\n", 
-	fq.Limit, fq.Offset, fq.Search, fq.Tags)	
+	sort, err := parseFeedSort(fq.Sort)
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+	fq.Sort = sort
+
+	if fq.Since != "" && fq.Until != "" && fq.Since > fq.Until {
+		app.badRequestError(w, r, "since must not be after until")
+		return
+	}
 
-	if err := Validate.Struct(fq); err != nil {
+	// The feed has its own sorts, checked above.
+	if err := Validate.StructExcept(fq, "Sort"); err != nil {
 		writeJSONError(w, http.StatusBadRequest, "Bad Request")
 		return
 	}
 
+	qs := r.URL.Query()
+
+	var cursor *store.Cursor
+
+	if c := qs.Get("cursor"); c != "" {
+		if qs.Get("offset") != "" {
+			app.badRequestError(w, r, "cursor and offset can't be combined")
+			return
+		}
+
+		decoded, err := store.DecodeCursor(c)
+		if err != nil {
+			app.badRequestError(w, r, err.Error())
+			return
+		}
+		cursor = &decoded
+	}
+
 	ctx := r.Context()
 
 	user, err := getUserFromContext(ctx)
@@ -59,19 +99,112 @@ func(app *application) getUserFeedHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	fmt.Println("User ID: ", user.ID)
-
-	feed, err := app.store.Posts.GetUserFeed(ctx, int64(user.ID), fq)
+	feed, err := app.store.Posts.GetUserFeed(ctx, int64(user.ID), fq, cursor)
 
 
 	if err != nil {
-		app.badRequestError(w, r, err.Error())
+		app.internalServerError(w, r, err.Error())
 		return
 	}
 
-	if err := writeJSON(w, http.StatusOK, feed); err != nil {
+	for i := range feed {
+		if err := app.renderPostContent(ctx, &feed[i].Post); err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+	}
+
+	page := feedPage{Posts: feed}
+
+	if len(feed) > 0 {
+		// Walking forwards, a full page may be followed by more and anything
+		// but the first page has something before it; walking backwards it
+		// is the other way round.
+		backward := cursor != nil && cursor.Before
+		started := cursor != nil || fq.Offset > 0
+
+		hasNext := len(feed) == fq.Limit
+		hasPrev := started
+		if backward {
+			hasNext, hasPrev = true, len(feed) == fq.Limit
+		}
+
+		if hasNext {
+			page.NextCursor, err = feedCursor(feed[len(feed)-1], fq.Sort, false)
+			if err != nil {
+				app.internalServerError(w, r, err.Error())
+				return
+			}
+		}
+
+		if hasPrev {
+			page.PrevCursor, err = feedCursor(feed[0], fq.Sort, true)
+			if err != nil {
+				app.internalServerError(w, r, err.Error())
+				return
+			}
+		}
+	}
+
+	setFeedLinks(w, r, page)
+
+	if err := writeJSON(w, http.StatusOK, page); err != nil {
 		app.internalServerError(w, r, err.Error())
 		return
 	}
 
-}
\ No newline at end of file
+}
+
+// parseFeedSort checks a feed sort, accepting the asc and desc the feed
+// used to take for oldest and newest.
+func parseFeedSort(sort string) (string, error) {
+	switch sort {
+	case "desc":
+		return store.FeedSortNewest, nil
+	case "asc":
+		return store.FeedSortOldest, nil
+	case store.FeedSortNewest, store.FeedSortOldest, store.FeedSortMostCommented, store.FeedSortMostReacted:
+		return sort, nil
+	default:
+		return "", errors.New("sort must be one of newest, oldest, most_commented or most_reacted")
+	}
+}
+
+// feedCursor encodes the position of a feed item in a feed sorted by sort.
+func feedCursor(post store.PostWithMetadata, sort string, before bool) (string, error) {
+	cursor, err := store.FeedCursor(post, sort)
+	if err != nil {
+		return "", err
+	}
+
+	cursor.Before = before
+
+	return cursor.Encode(), nil
+}
+
+// setFeedLinks sets a Link header pointing at the pages next to the current
+// one, keeping the request's other query parameters.
+func setFeedLinks(w http.ResponseWriter, r *http.Request, page feedPage) {
+	links := []string{}
+
+	for _, link := range []struct{ rel, cursor string }{
+		{"next", page.NextCursor},
+		{"prev", page.PrevCursor},
+	} {
+		if link.cursor == "" {
+			continue
+		}
+
+		qs := r.URL.Query()
+		qs.Del("offset")
+		qs.Set("cursor", link.cursor)
+
+		u := url.URL{Path: r.URL.Path, RawQuery: qs.Encode()}
+
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), link.rel))
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"social/internal/store"
+	"testing"
+)
+
+func TestParseFeedSort(t *testing.T) {
+	tests := map[string]string{
+		"desc":                      store.FeedSortNewest,
+		"asc":                       store.FeedSortOldest,
+		store.FeedSortNewest:        store.FeedSortNewest,
+		store.FeedSortOldest:        store.FeedSortOldest,
+		store.FeedSortMostCommented: store.FeedSortMostCommented,
+		store.FeedSortMostReacted:   store.FeedSortMostReacted,
+	}
+
+	for sort, want := range tests {
+		got, err := parseFeedSort(sort)
+		if err != nil {
+			t.Errorf("%q: got %v, want %q", sort, err, want)
+			continue
+		}
+
+		if got != want {
+			t.Errorf("%q: got %q, want %q", sort, got, want)
+		}
+	}
+
+	for _, sort := range []string{"", "top", "DESC", "created_at; DROP TABLE posts"} {
+		if _, err := parseFeedSort(sort); err == nil {
+			t.Errorf("%q: got no error", sort)
+		}
+	}
+}
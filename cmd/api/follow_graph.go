@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"social/internal/store"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxFollowImportRows caps how many users a single import can name.
+const maxFollowImportRows = 5000
+
+// maxFollowImportIdentifierLength is the longest username or email an
+// import row can hold.
+const maxFollowImportIdentifierLength = 255
+
+// exportFollowingHandler godoc
+//
+//	@Summary		Exports the users you follow
+//	@Description	Exports every user the authenticated user follows, oldest first, as JSON or as a CSV file that can be imported back
+//	@Tags			users
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			format	query		string	false	"json (default) or csv"
+//	@Success		200		{object}	[]store.FollowExportEntry
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/following/export [get]
+func (app *application) exportFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	app.writeFollowExport(w, r, "following", app.store.Followers.ExportFollowing)
+}
+
+// exportFollowersHandler godoc
+//
+//	@Summary		Exports your followers
+//	@Description	Exports every user following the authenticated user, oldest first, as JSON or CSV
+//	@Tags			users
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			format	query		string	false	"json (default) or csv"
+//	@Success		200		{object}	[]store.FollowExportEntry
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/followers/export [get]
+func (app *application) exportFollowersHandler(w http.ResponseWriter, r *http.Request) {
+	app.writeFollowExport(w, r, "followers", app.store.Followers.ExportFollowers)
+}
+
+// writeFollowExport writes the list returned by export in the requested
+// format. name is used for the CSV file name.
+func (app *application) writeFollowExport(w http.ResponseWriter, r *http.Request, name string, export func(context.Context, int64) ([]store.FollowExportEntry, error)) {
+	format := r.URL.Query().Get("format")
+
+	if format != "" && format != "json" && format != "csv" {
+		app.badRequestError(w, r, "format must be json or csv")
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	entries, err := export(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if format != "csv" {
+		if err := writeJSON(w, http.StatusOK, entries); err != nil {
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+
+	cw.Write([]string{"username", "followed_at"})
+	for _, entry := range entries {
+		cw.Write([]string{entry.Username, entry.FollowedAt})
+	}
+
+	cw.Flush()
+
+	if err := cw.Error(); err != nil {
+		app.logger.Warnw("Failed to write follow export", "error", err)
+	}
+}
+
+type followImportPayload struct {
+	Identifiers []string `json:"identifiers" validate:"required,min=1,dive,required,max=255"`
+}
+
+// importFollowsHandler godoc
+//
+//	@Summary		Imports a list of users to follow
+//	@Description	Queues an import that follows every listed user, named by username or email. The list is sent as JSON, or as a CSV file whose first column holds the identifiers; a header row is skipped. Private accounts get a follow request, blocked users are skipped, and the import pauses when the follow rate limit is reached.
+//	@Tags			users
+//	@Accept			json
+//	@Accept			text/csv
+//	@Produce		json
+//	@Param			payload	body		followImportPayload	true	"Users to follow"
+//	@Success		202		{object}	store.FollowImport
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/following/import [post]
+func (app *application) importFollowsHandler(w http.ResponseWriter, r *http.Request) {
+	var identifiers []string
+
+	// firstLine is the line of the request the first identifier came from.
+	firstLine := 1
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType == "text/csv" {
+		var err error
+
+		identifiers, firstLine, err = readCSVIdentifiers(w, r)
+		if err != nil {
+			app.badRequestError(w, r, err.Error())
+			return
+		}
+	} else {
+		var payload followImportPayload
+
+		if err := readJSON(w, r, &payload); err != nil {
+			app.badRequestError(w, r, err.Error())
+			return
+		}
+
+		if err := Validate.Struct(payload); err != nil {
+			app.badRequestError(w, r, err.Error())
+			return
+		}
+
+		identifiers = payload.Identifiers
+	}
+
+	rows, err := normalizeIdentifiers(identifiers, firstLine)
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if len(rows) == 0 {
+		app.badRequestError(w, r, "no users to import")
+		return
+	}
+
+	if len(rows) > maxFollowImportRows {
+		app.badRequestError(w, r, fmt.Sprintf("an import can list at most %d users", maxFollowImportRows))
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	imp := &store.FollowImport{UserID: user.ID}
+
+	if err := app.store.FollowImports.Create(ctx, imp, rows); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusAccepted, imp); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// readCSVIdentifiers reads the first column of a CSV request body, skipping
+// a header row. It also returns the line the first identifier is on.
+func readCSVIdentifiers(w http.ResponseWriter, r *http.Request) ([]string, int, error) {
+	maxBytes := 1_048_576
+
+	reader := csv.NewReader(http.MaxBytesReader(w, r.Body, int64(maxBytes)))
+	reader.FieldsPerRecord = -1
+
+	identifiers := []string{}
+	firstLine := 1
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		value := strings.TrimSpace(record[0])
+
+		if first {
+			switch strings.ToLower(value) {
+			case "username", "email", "identifier":
+				firstLine = 2
+				continue
+			}
+		}
+
+		identifiers = append(identifiers, value)
+	}
+
+	return identifiers, firstLine, nil
+}
+
+// normalizeIdentifiers trims whitespace and a leading @ from each
+// identifier, dropping empty and repeated ones, and numbers the rows it keeps
+// with their line counted from firstLine. It rejects identifiers too long to
+// be a username or email.
+func normalizeIdentifiers(identifiers []string, firstLine int) ([]store.FollowImportRow, error) {
+	seen := map[string]bool{}
+	normalized := []store.FollowImportRow{}
+
+	for i, identifier := range identifiers {
+		identifier = strings.TrimPrefix(strings.TrimSpace(identifier), "@")
+
+		if utf8.RuneCountInString(identifier) > maxFollowImportIdentifierLength {
+			return nil, fmt.Errorf("line %d: identifiers can be at most %d characters", firstLine+i, maxFollowImportIdentifierLength)
+		}
+
+		if identifier == "" || seen[identifier] {
+			continue
+		}
+		seen[identifier] = true
+
+		normalized = append(normalized, store.FollowImportRow{Line: firstLine + i, Identifier: identifier})
+	}
+
+	return normalized, nil
+}
+
+// getFollowImportHandler godoc
+//
+//	@Summary		Fetches a follow import
+//	@Description	Fetches the progress of one of the authenticated user's imports and the result of every row
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			importID	path		int	true	"Import ID"
+//	@Success		200			{object}	store.FollowImport
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/following/imports/{importID} [get]
+func (app *application) getFollowImportHandler(w http.ResponseWriter, r *http.Request) {
+	importID, err := strconv.ParseInt(chi.URLParam(r, "importID"), 10, 64)
+	if err != nil || importID < 1 {
+		app.badRequestError(w, r, "invalid import id")
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	imp, err := app.store.FollowImports.GetByID(ctx, importID, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, imp); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// processFollowImports works through the queued imports batch by batch,
+// pausing between batches. An import whose user reaches the follow rate
+// limit goes back in the queue until the window has passed.
+func (app *application) processFollowImports(ctx context.Context) error {
+	cfg := app.config.followImports
+
+	limit := store.FollowRateLimit{Max: cfg.rateLimit, Window: cfg.rateLimitWindow}
+
+	for {
+		imp, err := app.store.FollowImports.Claim(ctx)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		for {
+			settled, limited, err := app.store.FollowImports.ProcessBatch(ctx, imp, cfg.batchSize, limit)
+			if err != nil {
+				return err
+			}
+
+			for _, row := range settled {
+				if row.Status == store.FollowImportRowFollowed {
+					app.publishFollow(ctx, imp.UserID, *row.UserID)
+				}
+			}
+
+			if limited {
+				if err := app.store.FollowImports.Defer(ctx, imp.ID, time.Now().Add(cfg.rateLimitWindow)); err != nil {
+					return err
+				}
+				break
+			}
+
+			if len(settled) == 0 {
+				if err := app.store.FollowImports.Complete(ctx, imp.ID); err != nil {
+					return err
+				}
+				app.logger.Infow("follow import completed", "import", imp.ID, "rows", imp.Processed)
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(cfg.batchDelay):
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"social/internal/store"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type followPage struct {
+	Users      []store.FollowListEntry `json:"users"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+}
+
+// getFollowersHandler godoc
+//
+//	@Summary		Lists a user's followers
+//	@Description	Lists the users following a user, most recent first, flagged with whether the viewer follows them and whether they follow the viewer. A private account's lists are only shown to the account and its approved followers.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int		true	"User ID"
+//	@Param			cursor	query		string	false	"Cursor from a previous page"
+//	@Param			limit	query		int		false	"Limit"
+//	@Success		200		{object}	followPage
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/followers [get]
+func (app *application) getFollowersHandler(w http.ResponseWriter, r *http.Request) {
+	app.writeFollowPage(w, r, app.store.Followers.GetFollowers)
+}
+
+// getFollowingHandler godoc
+//
+//	@Summary		Lists the users a user follows
+//	@Description	Lists the users a user follows, most recent first, flagged with whether the viewer follows them and whether they follow the viewer. A private account's lists are only shown to the account and its approved followers.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int		true	"User ID"
+//	@Param			cursor	query		string	false	"Cursor from a previous page"
+//	@Param			limit	query		int		false	"Limit"
+//	@Success		200		{object}	followPage
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/following [get]
+func (app *application) getFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	app.writeFollowPage(w, r, app.store.Followers.GetFollowing)
+}
+
+type followListFunc func(ctx context.Context, userID, viewerID int64, cursor *store.Cursor, limit int) ([]store.FollowListEntry, error)
+
+// writeFollowPage parses the user, cursor and limit shared by the followers
+// and following endpoints and writes the page returned by list.
+func (app *application) writeFollowPage(w http.ResponseWriter, r *http.Request, list followListFunc) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil || userID < 1 {
+		app.badRequestError(w, r, "invalid user id")
+		return
+	}
+
+	qs := r.URL.Query()
+
+	limit := 20
+
+	if l := qs.Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > 100 {
+			app.badRequestError(w, r, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	var cursor *store.Cursor
+
+	if c := qs.Get("cursor"); c != "" {
+		decoded, err := store.DecodeCursor(c)
+		if err != nil {
+			app.badRequestError(w, r, err.Error())
+			return
+		}
+		cursor = &decoded
+	}
+
+	ctx := r.Context()
+
+	viewer, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	user, err := app.store.Users.GetById(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	hidden, err := app.hiddenByBlock(ctx, viewer.ID, int64(userID))
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if hidden {
+		app.notFoundError(w, r, store.ErrNotFound.Error())
+		return
+	}
+
+	// A private account's connections are shown to the same people as its
+	// posts.
+	allowed, err := app.canViewPosts(ctx, viewer, user)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if !allowed {
+		app.forbiddenError(w, r, "this account is private")
+		return
+	}
+
+	page := followPage{}
+
+	page.Users, err = list(ctx, int64(userID), viewer.ID, cursor, limit)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if len(page.Users) == limit {
+		last := page.Users[len(page.Users)-1]
+
+		next, err := store.NewCursor(last.FollowedAt, last.User.ID)
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		page.NextCursor = next.Encode()
+	}
+
+	if err := writeJSON(w, http.StatusOK, page); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// startJobs launches the periodic background jobs. They stop once ctx is
+// cancelled.
+func (app *application) startJobs(ctx context.Context) {
+	app.runPeriodic(ctx, "trending", app.config.trending.interval, app.computeTrending)
+	app.runPeriodic(ctx, "polls-finalize", app.config.pollFinalizeInterval, app.finalizePolls)
+	app.runPeriodic(ctx, "trash-purge", app.config.trash.purgeInterval, app.purgeTrash)
+	app.runPeriodic(ctx, "follow-suggestions", app.config.suggestions.interval, app.computeSuggestions)
+	app.runPeriodic(ctx, "follow-imports", app.config.followImports.interval, app.processFollowImports)
+}
+
+// runPeriodic runs job right away and then every interval. Failures are
+// logged and retried on the next tick. A job without a positive interval is
+// never started.
+func (app *application) runPeriodic(ctx context.Context, name string, interval time.Duration, job func(context.Context) error) {
+	if interval <= 0 {
+		app.logger.Errorw("job not started", "job", name, "error", "interval must be positive", "interval", interval.String())
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			start := time.Now()
+
+			if err := job(ctx); err != nil && ctx.Err() == nil {
+				app.logger.Errorw("job failed", "job", name, "error", err)
+			} else {
+				app.logger.Infow("job completed", "job", name, "duration", time.Since(start).String())
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
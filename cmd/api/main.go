@@ -4,6 +4,7 @@ import (
 	"social/internal/auth"
 	"social/internal/db"
 	"social/internal/env"
+	"social/internal/events"
 	"social/internal/mailer"
 	"social/internal/store"
 	"social/internal/store/cache"
@@ -58,6 +59,30 @@ func main() {
 			},
 		},
 		frontendURL: env.GetString("FRONTEND_URL", "http://localhost:4000"),
+		trending: trendingConfig{
+			interval:      env.GetDuration("TRENDING_INTERVAL", time.Minute*5),
+			defaultWindow: env.GetString("TRENDING_DEFAULT_WINDOW", "24h"),
+			limit:         env.GetInt("TRENDING_LIMIT", 50),
+		},
+		maxPinnedPosts: env.GetInt("MAX_PINNED_POSTS", 3),
+		pollFinalizeInterval: env.GetDuration("POLL_FINALIZE_INTERVAL", time.Minute),
+		commentsPreviewSize: env.GetInt("COMMENTS_PREVIEW_SIZE", 3),
+		trash: trashConfig{
+			retention:     time.Duration(env.GetInt("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour,
+			purgeInterval: env.GetDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		},
+		suggestions: suggestionsConfig{
+			interval:       env.GetDuration("SUGGESTIONS_INTERVAL", time.Hour),
+			activityWindow: env.GetDuration("SUGGESTIONS_ACTIVITY_WINDOW", 7*24*time.Hour),
+			perUser:        env.GetInt("SUGGESTIONS_PER_USER", 50),
+		},
+		followImports: followImportsConfig{
+			interval:        env.GetDuration("FOLLOW_IMPORT_INTERVAL", 30*time.Second),
+			batchSize:       env.GetInt("FOLLOW_IMPORT_BATCH_SIZE", 100),
+			batchDelay:      env.GetDuration("FOLLOW_IMPORT_BATCH_DELAY", time.Second),
+			rateLimit:       env.GetInt("FOLLOW_RATE_LIMIT", 400),
+			rateLimitWindow: env.GetDuration("FOLLOW_RATE_LIMIT_WINDOW", 24*time.Hour),
+		},
 		auth: authconfig{
 			basic: basicconfig{
 				user: env.GetString("BASIC_AUTH_USER", "admin"),
@@ -76,6 +101,17 @@ func main() {
 	logger := zap.Must(zap.NewProduction()).Sugar()
 	defer logger.Sync()
 
+	windows, err := parseWindows(env.GetString("TRENDING_WINDOWS", "1h,24h,7d"))
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	cfg.trending.windows = windows
+
+	if err := cfg.validate(); err != nil {
+		logger.Fatal(err)
+	}
+
 	db, err := db.New(
 		cfg.dbconn.addr,
 		cfg.dbconn.maxOpenConns,
@@ -111,6 +147,8 @@ func main() {
 
 	jwtAuth := auth.NewJWTAuthenticator(cfg.auth.token.secret, cfg.auth.token.iss, cfg.auth.token.aud)
 
+	publisher := events.NewLogPublisher(logger)
+
 	app := &application{
 		config: cfg,
 		store:  store,
@@ -118,6 +156,7 @@ func main() {
 		logger: logger,	
 		mailer: mailer,	
 		authenticator: jwtAuth,
+		events: publisher,
 
 	}
 
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"social/internal/entities"
+	"social/internal/events"
+	"social/internal/store"
+)
+
+// mentionCandidates parses the mentions out of content, to be resolved when
+// the post or comment holding them is stored.
+func mentionCandidates(content string) []store.Mention {
+	candidates := []store.Mention{}
+	for _, m := range entities.Mentions(content) {
+		candidates = append(candidates, store.Mention{
+			Username: m.Text,
+			Offset:   m.Offset,
+			Length:   m.Length,
+		})
+	}
+
+	return candidates
+}
+
+// notifyMentions notifies every user in mentions, stored for the post or
+// comment, that wasn't in previous.
+func (app *application) notifyMentions(ctx context.Context, postID int64, commentID *int64, authorID int64, mentions, previous []store.Mention) {
+	notified := map[int64]bool{authorID: true}
+	for _, m := range previous {
+		notified[m.UserID] = true
+	}
+
+	for _, m := range mentions {
+		if notified[m.UserID] {
+			continue
+		}
+		notified[m.UserID] = true
+
+		event := events.Event{
+			Type:    events.TypeMention,
+			ActorID: authorID,
+			UserID:  m.UserID,
+			PostID:  postID,
+		}
+
+		if commentID != nil {
+			event.CommentID = *commentID
+		}
+
+		if err := app.events.Publish(ctx, event); err != nil {
+			app.logger.Warnw("Failed to publish mention event", "error", err)
+		}
+	}
+}
+
+// getUserMentionsHandler godoc
+//
+//	@Summary		Fetches the posts and comments mentioning the user
+//	@Description	Fetches the posts and comments mentioning the authenticated user
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Param			sort	query		string	false	"Sort"
+//	@Success		200		{object}	[]store.MentionWithContext
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/mentions [get]
+func (app *application) getUserMentionsHandler(w http.ResponseWriter, r *http.Request) {
+	fq := store.PaginatedFieldQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "desc",
+	}
+
+	if err := fq.Parse(r); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	mentions, err := app.store.Mentions.GetByUserID(ctx, user.ID, fq)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, mentions); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"social/internal/store"
@@ -116,10 +117,15 @@ func (app *application) checkPostOwnership(requiredRole string, next http.Handle
 			return
 		}
 
-		post, err := app.store.Posts.GetById(r.Context(), postID)
+		post, err := app.getPostForUser(r.Context(), postID, user)
 
 		if err != nil {
-			app.notFoundError(w, r, "post don't exist")
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundError(w, r, "post don't exist")
+			default:
+				app.internalServerError(w, r, err.Error())
+			}
 			return
 		}
 
@@ -145,6 +151,40 @@ func (app *application) checkPostOwnership(requiredRole string, next http.Handle
 	})
 }
 
+// checkCommentOwnership lets the comment's author through, and anyone else
+// whose role is at least requiredRole. It expects commentsContextMiddleware
+// to have run.
+func (app *application) checkCommentOwnership(requiredRole string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(userContext).(*store.User)
+
+		comment, err := getCommentFromContext(r.Context())
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		if int64(comment.UserID) == user.ID {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, err := app.checkRolePrecedance(r.Context(), user, requiredRole)
+
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		if !allowed {
+			app.forbiddenError(w, r, "forbidden")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (app *application) checkRolePrecedance(ctx context.Context, user *store.User, rolename string) (bool, error) {
 	role, err := app.store.Roles.GetByName(ctx, rolename)
 
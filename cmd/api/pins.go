@@ -0,0 +1,287 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"social/internal/store"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// pinPostHandler godoc
+//
+//	@Summary		Pins a post
+//	@Description	Pins a post to its author's profile. Only the author or a moderator can pin.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"Post ID"
+//	@Success		204	{object}	string
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/pin [put]
+func (app *application) pinPostHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := app.store.Pins.Pin(ctx, post.UserId, int64(post.ID), app.config.maxPinnedPosts); err != nil {
+		switch {
+		case errors.Is(err, store.ErrTooManyPins):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unpinPostHandler godoc
+//
+//	@Summary		Unpins a post
+//	@Description	Removes a post from its author's pinned posts
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"Post ID"
+//	@Success		204	{object}	string
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/pin [delete]
+func (app *application) unpinPostHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := app.store.Pins.Unpin(ctx, post.UserId, int64(post.ID)); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type reorderPinsPayload struct {
+	PostIDs []int64 `json:"post_ids" validate:"required"`
+}
+
+// reorderPinsHandler godoc
+//
+//	@Summary		Reorders pinned posts
+//	@Description	Sets the order of a user's pinned posts. Only the user or a moderator can reorder.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int					true	"User ID"
+//	@Param			payload	body		reorderPinsPayload	true	"Pinned post IDs in order"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/pins [put]
+func (app *application) reorderPinsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil || userID < 1 {
+		app.badRequestError(w, r, "invalid user id")
+		return
+	}
+
+	var payload reorderPinsPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if user.ID != userID {
+		allowed, err := app.checkRolePrecedance(ctx, user, "moderator")
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		if !allowed {
+			app.forbiddenError(w, r, "forbidden")
+			return
+		}
+	}
+
+	if err := app.store.Pins.Reorder(ctx, userID, payload.PostIDs); err != nil {
+		switch {
+		case errors.Is(err, store.ErrInvalidPinOrder):
+			app.badRequestError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type userTimeline struct {
+	Pinned     []store.PostWithMetadata `json:"pinned"`
+	Posts      []store.PostWithMetadata `json:"posts"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+// getUserPostsHandler godoc
+//
+//	@Summary		Fetches a user's profile timeline
+//	@Description	Fetches a user's pinned posts followed by their posts, newest first. Pinned posts are only returned on the first page. A private account's timeline is only visible to its approved followers.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int		true	"User ID"
+//	@Param			cursor	query		string	false	"Cursor from a previous page"
+//	@Param			limit	query		int		false	"Limit"
+//	@Success		200		{object}	userTimeline
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/posts [get]
+func (app *application) getUserPostsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil || userID < 1 {
+		app.badRequestError(w, r, "invalid user id")
+		return
+	}
+
+	qs := r.URL.Query()
+
+	limit := 20
+
+	if l := qs.Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > 100 {
+			app.badRequestError(w, r, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	var cursor *store.Cursor
+
+	if c := qs.Get("cursor"); c != "" {
+		decoded, err := store.DecodeCursor(c)
+		if err != nil {
+			app.badRequestError(w, r, err.Error())
+			return
+		}
+		cursor = &decoded
+	}
+
+	ctx := r.Context()
+
+	author, err := app.store.Users.GetById(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	viewer, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	hidden, err := app.hiddenByBlock(ctx, viewer.ID, author.ID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if hidden {
+		app.notFoundError(w, r, store.ErrNotFound.Error())
+		return
+	}
+
+	allowed, err := app.canViewPosts(ctx, viewer, author)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if !allowed {
+		app.forbiddenError(w, r, "this account is private")
+		return
+	}
+
+	timeline := userTimeline{Pinned: []store.PostWithMetadata{}}
+
+	if cursor == nil {
+		timeline.Pinned, err = app.store.Pins.GetByUserID(ctx, int64(userID), viewer.ID)
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+	}
+
+	timeline.Posts, err = app.store.Posts.GetByUserID(ctx, int64(userID), viewer.ID, cursor, limit)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if len(timeline.Posts) == limit {
+		last := timeline.Posts[len(timeline.Posts)-1]
+
+		next, err := store.NewCursor(last.CreatedAt, int64(last.ID))
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		timeline.NextCursor = next.Encode()
+	}
+
+	for _, posts := range [][]store.PostWithMetadata{timeline.Pinned, timeline.Posts} {
+		for i := range posts {
+			if err := app.renderPostContent(ctx, &posts[i].Post); err != nil {
+				app.internalServerError(w, r, err.Error())
+				return
+			}
+		}
+	}
+
+	if err := writeJSON(w, http.StatusOK, timeline); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"social/internal/store"
+	"time"
+)
+
+type createPollPayload struct {
+	Options        []string  `json:"options" validate:"required,min=2,max=10,dive,required,max=100"`
+	MultipleChoice bool      `json:"multiple_choice"`
+	ClosesAt       time.Time `json:"closes_at" validate:"required"`
+}
+
+func (p *createPollPayload) toPoll() (*store.Poll, error) {
+	if !p.ClosesAt.After(time.Now()) {
+		return nil, errors.New("poll must close in the future")
+	}
+
+	poll := &store.Poll{
+		MultipleChoice: p.MultipleChoice,
+		ClosesAt:       p.ClosesAt.UTC().Format(time.RFC3339),
+	}
+
+	for _, text := range p.Options {
+		poll.Options = append(poll.Options, store.PollOption{Text: text})
+	}
+
+	return poll, nil
+}
+
+type votePollPayload struct {
+	OptionIDs []int64 `json:"option_ids" validate:"required,min=1,dive,gte=1"`
+}
+
+// votePollHandler godoc
+//
+//	@Summary		Votes in a post's poll
+//	@Description	Casts the user's single ballot in the poll attached to a post
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"Post ID"
+//	@Param			payload	body		votePollPayload	true	"Chosen options"
+//	@Success		201		{object}	store.Poll
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/poll/votes [post]
+func (app *application) votePollHandler(w http.ResponseWriter, r *http.Request) {
+	var payload votePollPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	poll, err := app.store.Polls.GetByPostID(ctx, int64(post.ID), user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, "post has no poll")
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	if !poll.MultipleChoice && len(payload.OptionIDs) > 1 {
+		app.badRequestError(w, r, "poll allows a single choice")
+		return
+	}
+
+	if err := app.store.Polls.Vote(ctx, poll.ID, user.ID, payload.OptionIDs); err != nil {
+		switch {
+		case errors.Is(err, store.ErrAlreadyVoted):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, store.ErrPollClosed), errors.Is(err, store.ErrInvalidPollOption):
+			app.badRequestError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	poll, err = app.store.Polls.GetByPostID(ctx, int64(post.ID), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, poll); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// finalizePolls freezes the results of polls whose closing time has passed.
+func (app *application) finalizePolls(ctx context.Context) error {
+	finalized, err := app.store.Polls.FinalizeClosed(ctx)
+	if err != nil {
+		return err
+	}
+
+	if finalized > 0 {
+		app.logger.Infow("polls finalized", "count", finalized)
+	}
+
+	return nil
+}
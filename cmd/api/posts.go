@@ -1,19 +1,30 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"log"
 	"net/http"
+	"social/internal/markdown"
 	"social/internal/store"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-redis/redis/v8"
 )
 
 type CreatePostPayload struct {
-	Title   string   `json:"title" validate:"required,max=100"`
-	Content string   `json:"content" validate:"required,max=1000"`
-	Tags    []string `json:"tags"`
+	Title         string             `json:"title" validate:"required,max=100"`
+	Content       string             `json:"content" validate:"required,max=1000"`
+	ContentFormat string             `json:"content_format" validate:"omitempty,oneof=plain markdown"`
+	Tags          []string           `json:"tags"`
+	QuoteOfID     *int64             `json:"quote_of_id" validate:"omitempty,gte=1"`
+	InReplyToID   *int64             `json:"in_reply_to_id" validate:"omitempty,gte=1"`
+	Poll          *createPollPayload `json:"poll" validate:"omitempty"`
+	CommentPolicy string             `json:"comment_policy" validate:"omitempty,oneof=everyone followers mentioned nobody approval"`
+	// AudienceListID shares the post with the members of one of the
+	// author's audience lists only.
+	AudienceListID *int64 `json:"audience_list_id" validate:"omitempty,gte=1"`
 }
 
 // CreatePost godoc
@@ -51,27 +62,106 @@ func (app *application) createPostsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	post := &store.Post{
-		Title:   payload.Title,
-		Content: payload.Content,
-		//Todo : change after auth
-		Tags:   payload.Tags,
-		UserId: user.ID,
-	}
-
 	ctx := r.Context()
 
+	post, ok := app.preparePost(w, r, payload, user.ID)
+	if !ok {
+		return
+	}
+
 	if err := app.store.Posts.Create(ctx, post); err != nil {
 		app.internalServerError(w, r, err.Error())
 		return
 	}
 
+	app.notifyMentions(ctx, int64(post.ID), nil, user.ID, post.Mentions, nil)
+
+	if err := app.renderPostContent(ctx, post); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
 	if err := writeJSON(w, http.StatusCreated, post); err != nil {
 		app.internalServerError(w, r, err.Error())
 		return
 	}
 }
 
+// preparePost turns a create payload into a post ready to be stored,
+// checking the posts it refers to. It writes the error response itself and
+// reports whether the caller may go on.
+func (app *application) preparePost(w http.ResponseWriter, r *http.Request, payload CreatePostPayload, userID int64) (*store.Post, bool) {
+	tags, err := normalizeTags(payload.Tags, payload.Content)
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return nil, false
+	}
+
+	ctx := r.Context()
+
+	references := []struct {
+		id       *int64
+		notFound string
+	}{
+		{payload.QuoteOfID, "quoted post not found"},
+		{payload.InReplyToID, "replied-to post not found"},
+	}
+
+	for _, ref := range references {
+		if ref.id == nil {
+			continue
+		}
+
+		if _, err := app.store.Posts.GetById(ctx, int(*ref.id), userID); err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.badRequestError(w, r, ref.notFound)
+			default:
+				app.internalServerError(w, r, err.Error())
+			}
+			return nil, false
+		}
+	}
+
+	post := &store.Post{
+		Title:         payload.Title,
+		Content:       payload.Content,
+		ContentFormat: payload.ContentFormat,
+		//Todo : change after auth
+		Tags:          tags,
+		UserId:        userID,
+		QuoteOfID:     payload.QuoteOfID,
+		InReplyToID:   payload.InReplyToID,
+		CommentPolicy: payload.CommentPolicy,
+		Mentions:      mentionCandidates(payload.Content),
+	}
+
+	if payload.AudienceListID != nil {
+		if _, err := app.store.AudienceLists.GetByID(ctx, *payload.AudienceListID, userID); err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.badRequestError(w, r, "audience list not found")
+			default:
+				app.internalServerError(w, r, err.Error())
+			}
+			return nil, false
+		}
+
+		post.Audience = store.PostAudienceList
+		post.AudienceListID = payload.AudienceListID
+	}
+
+	if payload.Poll != nil {
+		post.Poll, err = payload.Poll.toPoll()
+		if err != nil {
+			app.badRequestError(w, r, err.Error())
+			return nil, false
+		}
+	}
+
+	return post, true
+}
+
 // GetPost godoc
 //
 //	@Summary		Fetches a post
@@ -98,7 +188,13 @@ func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := app.store.Posts.GetById(ctx, idAsInt)
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	post, err := app.store.Posts.GetById(ctx, idAsInt, user.ID)
 
 	if err != nil {
 		switch {
@@ -110,14 +206,66 @@ func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	comments, err := app.store.Comments.GetByPostID(ctx, post.ID)
+	// Only a preview of the top comments is embedded, none when the preview
+	// is turned off; the full listing is paginated through
+	// /posts/{id}/comments.
+	comments := []store.Comment{}
+
+	if app.config.commentsPreviewSize > 0 {
+		comments, err = app.store.Comments.GetByPostID(ctx, post.ID, user.ID, store.CommentTreeQuery{
+			Sort:  store.CommentsSortTop,
+			Limit: app.config.commentsPreviewSize,
+			Depth: 1,
+		})
+
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+	}
+
+	mentions, err := app.store.Mentions.GetByPostID(ctx, int64(post.ID))
 
 	if err != nil {
 		app.internalServerError(w, r, err.Error())
 		return
 	}
 
-	post.Comments = comments
+	post.Mentions, post.Comments = splitMentions(mentions, comments)
+
+	poll, err := app.store.Polls.GetByPostID(ctx, int64(post.ID), user.ID)
+
+	switch {
+	case err == nil:
+		poll.HideResults()
+		post.Poll = poll
+	case !errors.Is(err, store.ErrNotFound):
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if post.QuoteOfID != nil {
+		quoted, err := app.store.Posts.GetById(ctx, int(*post.QuoteOfID), user.ID)
+
+		switch {
+		case err == nil:
+			if err := app.renderPostContent(ctx, quoted); err != nil {
+				app.internalServerError(w, r, err.Error())
+				return
+			}
+
+			post.QuotedPost = quoted
+		case !errors.Is(err, store.ErrNotFound):
+			// The quoted post may have been deleted since.
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+	}
+
+	if err := app.renderPostContent(ctx, post); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
 
 	if err := writeJSON(w, http.StatusOK, post); err != nil {
 		app.internalServerError(w, r, err.Error())
@@ -150,7 +298,13 @@ func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	post, err := app.store.Posts.GetById(ctx, idAsInt)
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	post, err := app.store.Posts.GetById(ctx, idAsInt, user.ID)
 
 	if err != nil {
 		switch {
@@ -171,8 +325,11 @@ func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request
 }
 
 type updatePostPayload struct {
-	Title   *string `json:"title" validate:"omitempty,max=100"`
-	Content *string `json:"content" validate:"omitempty,max=1000"`
+	Title         *string   `json:"title" validate:"omitempty,max=100"`
+	Content       *string   `json:"content" validate:"omitempty,max=1000"`
+	ContentFormat *string   `json:"content_format" validate:"omitempty,oneof=plain markdown"`
+	Tags          *[]string `json:"tags"`
+	CommentPolicy *string   `json:"comment_policy" validate:"omitempty,oneof=everyone followers mentioned nobody approval"`
 }
 
 // UpdatePost godoc
@@ -203,7 +360,13 @@ func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	post, err := app.store.Posts.GetById(ctx, idAsInt)
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	post, err := app.store.Posts.GetById(ctx, idAsInt, user.ID)
 
 	if err != nil {
 		switch {
@@ -237,11 +400,46 @@ func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request
 		post.Title = *payload.Title
 	}
 
+	if payload.ContentFormat != nil {
+		post.ContentFormat = *payload.ContentFormat
+	}
+
+	if payload.Tags != nil {
+		post.Tags = *payload.Tags
+	}
+
+	if payload.CommentPolicy != nil {
+		post.CommentPolicy = *payload.CommentPolicy
+	}
+
+	post.Tags, err = normalizeTags(post.Tags, post.Content)
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	previous, err := app.store.Mentions.GetByPostID(ctx, int64(post.ID))
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	previous, _ = splitMentions(previous, nil)
+
+	post.Mentions = mentionCandidates(post.Content)
+
 	if err := app.store.Posts.Update(ctx, post); err != nil {
 		app.internalServerError(w, r, err.Error())
 		return
 	}
 
+	app.notifyMentions(ctx, int64(post.ID), nil, post.UserId, post.Mentions, previous)
+
+	if err := app.renderPostContent(ctx, post); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
 	if err := writeJSON(w, http.StatusOK, post); err != nil {
 		app.internalServerError(w, r, err.Error())
 		return
@@ -251,7 +449,10 @@ func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request
 
 type createCommentPayload struct {
 	Content string `json:"content" validate:"required,max=1000"`
-	UserID  int    `json:"user_id" validate:"required"`
+	// Deprecated: comments are written by the authenticated user, whose
+	// access is checked against the post's comment policy.
+	UserID   int    `json:"user_id"`
+	ParentID *int64 `json:"parent_id" validate:"omitempty,gte=1"`
 }
 
 func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Request) {
@@ -279,10 +480,65 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	post, err := app.store.Posts.GetById(ctx, idAsInt, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	status, err := app.commentStatus(ctx, post, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCommentsNotAllowed):
+			app.forbiddenError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	if payload.ParentID != nil {
+		parent, err := app.store.Comments.GetByID(ctx, int(*payload.ParentID))
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		if parent == nil || parent.PostID != idAsInt || parent.Status != store.CommentStatusApproved {
+			app.badRequestError(w, r, "parent comment not found")
+			return
+		}
+
+		blocked, err := app.store.Blocks.IsBlocked(ctx, user.ID, int64(parent.UserID))
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		if blocked {
+			app.forbiddenError(w, r, "you can't reply to this comment")
+			return
+		}
+	}
+
 	comment := &store.Comment{
-		Content: payload.Content,
-		PostID:  idAsInt,
-		UserID:  payload.UserID,
+		Content:  payload.Content,
+		PostID:   idAsInt,
+		UserID:   int(user.ID),
+		ParentID: payload.ParentID,
+		Status:   status,
+		Mentions: mentionCandidates(payload.Content),
 	}
 
 	if err := app.store.Comments.Create(ctx, comment); err != nil {
@@ -290,6 +546,10 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	commentID := int64(comment.ID)
+
+	app.notifyMentions(ctx, int64(comment.PostID), &commentID, int64(comment.UserID), comment.Mentions, nil)
+
 	if err := writeJSON(w, http.StatusCreated, comment); err != nil {
 		app.internalServerError(w, r, err.Error())
 		return
@@ -297,5 +557,133 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 
 }
 
-// TODO : Add the delete comment handler method
+type postContextKey string
+
+const postContext postContextKey = "post"
+
+// postsContextMiddleware loads the post from the {id} URL parameter into the
+// request context.
+func (app *application) postsContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			app.badRequestError(w, r, err.Error())
+			return
+		}
+
+		user, err := getUserFromContext(ctx)
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		post, err := app.getPostForUser(ctx, id, user)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundError(w, r, err.Error())
+			default:
+				app.internalServerError(w, r, err.Error())
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, postContext, post)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// getPostForUser returns the post if user may see it. Moderators and admins
+// also get the posts they couldn't see otherwise, so they can act on them.
+func (app *application) getPostForUser(ctx context.Context, id int, user *store.User) (*store.Post, error) {
+	post, err := app.store.Posts.GetById(ctx, id, user.ID)
+	if !errors.Is(err, store.ErrNotFound) {
+		return post, err
+	}
+
+	moderator, roleErr := app.checkRolePrecedance(ctx, user, "moderator")
+	if roleErr != nil {
+		return nil, roleErr
+	}
+
+	if !moderator {
+		return nil, err
+	}
+
+	return app.store.Posts.GetByIdForModeration(ctx, id, user.ID)
+}
+
+func getPostFromContext(ctx context.Context) (*store.Post, error) {
+	post, ok := ctx.Value(postContext).(*store.Post)
+	if !ok {
+		return nil, errors.New("post not found in context")
+	}
+	return post, nil
+}
+
+// splitMentions separates the post's own mentions from those made in its
+// comments, attaching the latter to the matching comment and its replies.
+func splitMentions(mentions []store.Mention, comments []store.Comment) ([]store.Mention, []store.Comment) {
+	postMentions := []store.Mention{}
+	byComment := map[int64][]store.Mention{}
+
+	for _, m := range mentions {
+		if m.CommentID == nil {
+			postMentions = append(postMentions, m)
+			continue
+		}
+		byComment[*m.CommentID] = append(byComment[*m.CommentID], m)
+	}
+
+	attachMentions(byComment, comments)
+
+	return postMentions, comments
+}
+
+func attachMentions(byComment map[int64][]store.Mention, comments []store.Comment) {
+	for i := range comments {
+		comments[i].Mentions = []store.Mention{}
+
+		if !comments[i].Deleted && byComment[int64(comments[i].ID)] != nil {
+			comments[i].Mentions = byComment[int64(comments[i].ID)]
+		}
+
+		attachMentions(byComment, comments[i].Replies)
+	}
+}
+
+// renderPostContent fills ContentHTML, reusing the cached rendering for the
+// post's current version when Redis is enabled.
+func (app *application) renderPostContent(ctx context.Context, post *store.Post) error {
+	if app.config.redisCfg.enabled {
+		html, err := app.cacheStorage.Posts.GetContentHTML(ctx, int64(post.ID), post.Version)
+		if err == nil {
+			post.ContentHTML = html
+			return nil
+		}
+
+		if err != redis.Nil {
+			app.logger.Warnw("Failed to read cached post html", "error", err)
+		}
+	}
+
+	html, err := markdown.Render(post.Content, post.ContentFormat)
+	if err != nil {
+		return err
+	}
+
+	post.ContentHTML = html
+
+	if app.config.redisCfg.enabled {
+		if err := app.cacheStorage.Posts.SetContentHTML(ctx, int64(post.ID), post.Version, html); err != nil {
+			app.logger.Warnw("Failed to cache post html", "error", err)
+			// Continue anyway, just couldn't cache
+		}
+	}
+
+	return nil
+}
+
 // TODO : Add the middleware to fetch the user from the context
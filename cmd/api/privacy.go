@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"social/internal/store"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type updatePrivacyPayload struct {
+	IsPrivate *bool `json:"is_private" validate:"required"`
+}
+
+// updatePrivacyHandler godoc
+//
+//	@Summary		Sets whether the account is private
+//	@Description	Private accounts approve each follower and only show their posts to them. Making an account public approves every pending follow request.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		updatePrivacyPayload	true	"Privacy setting"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/privacy [put]
+func (app *application) updatePrivacyHandler(w http.ResponseWriter, r *http.Request) {
+	var payload updatePrivacyPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	followers, err := app.store.Users.SetPrivate(ctx, user.ID, *payload.IsPrivate)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	for _, followerID := range followers {
+		app.publishFollow(ctx, followerID, user.ID)
+	}
+
+	if err := app.cacheStorage.Users.Delete(ctx, user.ID); err != nil {
+		app.logger.Warnw("Failed to invalidate cached user", "error", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type followRequestsPage struct {
+	Requests   []store.FollowRequest `json:"requests"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// getFollowRequestsHandler godoc
+//
+//	@Summary		Lists incoming follow requests
+//	@Description	Lists the requests waiting on the authenticated user's approval, newest first
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			cursor	query		string	false	"Cursor from a previous page"
+//	@Param			limit	query		int		false	"Limit"
+//	@Success		200		{object}	followRequestsPage
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/follow-requests [get]
+func (app *application) getFollowRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	limit := 20
+
+	if l := qs.Get("limit"); l != "" {
+		var err error
+
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > 100 {
+			app.badRequestError(w, r, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	var cursor *store.Cursor
+
+	if c := qs.Get("cursor"); c != "" {
+		decoded, err := store.DecodeCursor(c)
+		if err != nil {
+			app.badRequestError(w, r, err.Error())
+			return
+		}
+		cursor = &decoded
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	page := followRequestsPage{}
+
+	page.Requests, err = app.store.FollowRequests.GetIncoming(ctx, user.ID, cursor, limit)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if len(page.Requests) == limit {
+		last := page.Requests[len(page.Requests)-1]
+
+		next, err := store.NewCursor(last.CreatedAt, last.RequesterID)
+		if err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+
+		page.NextCursor = next.Encode()
+	}
+
+	if err := writeJSON(w, http.StatusOK, page); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// approveFollowRequestHandler godoc
+//
+//	@Summary		Approves a follow request
+//	@Description	Approves a pending request, making the requester a follower
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			requesterID	path		int	true	"Requester ID"
+//	@Success		204			{object}	string
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/follow-requests/{requesterID}/approve [post]
+func (app *application) approveFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	app.resolveFollowRequest(w, r, func(ctx context.Context, userID, requesterID int64) error {
+		created, err := app.store.FollowRequests.Approve(ctx, userID, requesterID)
+		if err != nil {
+			return err
+		}
+
+		if created {
+			app.publishFollow(ctx, requesterID, userID)
+		}
+
+		return nil
+	})
+}
+
+// rejectFollowRequestHandler godoc
+//
+//	@Summary		Rejects a follow request
+//	@Description	Rejects a pending request without notifying the requester
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			requesterID	path		int	true	"Requester ID"
+//	@Success		204			{object}	string
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/follow-requests/{requesterID} [delete]
+func (app *application) rejectFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	app.resolveFollowRequest(w, r, app.store.FollowRequests.Delete)
+}
+
+// resolveFollowRequest applies resolve to the authenticated user's pending
+// request from the requester in the URL.
+func (app *application) resolveFollowRequest(w http.ResponseWriter, r *http.Request, resolve func(ctx context.Context, userID, requesterID int64) error) {
+	requesterID, err := strconv.ParseInt(chi.URLParam(r, "requesterID"), 10, 64)
+	if err != nil || requesterID < 1 {
+		app.badRequestError(w, r, "invalid requester id")
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := resolve(ctx, user.ID, requesterID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, "follow request not found")
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cancelFollowRequestHandler godoc
+//
+//	@Summary		Cancels a follow request
+//	@Description	Withdraws the authenticated user's pending request to follow a private account
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/follow-request [delete]
+func (app *application) cancelFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil || userID < 1 {
+		app.badRequestError(w, r, "invalid user id")
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := app.store.FollowRequests.Delete(ctx, userID, user.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, "follow request not found")
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// canViewPosts reports whether viewer may see author's posts: anyone can
+// see a public account's posts, but a private account's are limited to the
+// author and their approved followers.
+func (app *application) canViewPosts(ctx context.Context, viewer, author *store.User) (bool, error) {
+	if !author.IsPrivate || viewer.ID == author.ID {
+		return true, nil
+	}
+
+	return app.store.Followers.IsFollowing(ctx, viewer.ID, author.ID)
+}
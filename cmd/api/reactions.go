@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"social/internal/store"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// reactToPostHandler godoc
+//
+//	@Summary		Reacts to a post
+//	@Description	Adds a reaction of the given kind to a post. Repeating it is a no-op.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int		true	"Post ID"
+//	@Param			kind	path		string	true	"Reaction kind"
+//	@Success		201		{string}	string	"Reaction added"
+//	@Success		200		{string}	string	"Reaction already present"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/reactions/{kind} [put]
+func (app *application) reactToPostHandler(w http.ResponseWriter, r *http.Request) {
+	kind := chi.URLParam(r, "kind")
+	if !slices.Contains(store.ReactionKinds, kind) {
+		app.badRequestError(w, r, "unknown reaction kind")
+		return
+	}
+
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	status := http.StatusCreated
+
+	if err := app.store.Reactions.Add(ctx, int64(post.ID), user.ID, kind); err != nil {
+		switch {
+		case errors.Is(err, store.ErrAlreadyReacted):
+			status = http.StatusOK
+		default:
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+	}
+
+	writeJSON(w, status, nil)
+}
+
+// unreactToPostHandler godoc
+//
+//	@Summary		Removes a reaction from a post
+//	@Description	Removes the user's reaction of the given kind. Removing a missing reaction is a no-op.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int		true	"Post ID"
+//	@Param			kind	path		string	true	"Reaction kind"
+//	@Success		204		{object}	string
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/reactions/{kind} [delete]
+func (app *application) unreactToPostHandler(w http.ResponseWriter, r *http.Request) {
+	kind := chi.URLParam(r, "kind")
+	if !slices.Contains(store.ReactionKinds, kind) {
+		app.badRequestError(w, r, "unknown reaction kind")
+		return
+	}
+
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := app.store.Reactions.Remove(ctx, int64(post.ID), user.ID, kind); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getPostReactionsHandler godoc
+//
+//	@Summary		Lists who reacted to a post
+//	@Description	Lists the users who reacted to a post, optionally filtered by kind
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int		true	"Post ID"
+//	@Param			kind	query		string	false	"Reaction kind"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Param			sort	query		string	false	"Sort"
+//	@Success		200		{object}	[]store.Reaction
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/reactions [get]
+func (app *application) getPostReactionsHandler(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("kind")
+	if kind != "" && !slices.Contains(store.ReactionKinds, kind) {
+		app.badRequestError(w, r, "unknown reaction kind")
+		return
+	}
+
+	fq := store.PaginatedFieldQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "desc",
+	}
+
+	if err := fq.Parse(r); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	reactions, err := app.store.Reactions.GetByPostID(ctx, int64(post.ID), kind, fq)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, reactions); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"social/internal/store"
+)
+
+// repostHandler godoc
+//
+//	@Summary		Reposts a post
+//	@Description	Shares someone else's post into the user's followers' feeds. Repeating it is a no-op.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int		true	"Post ID"
+//	@Success		201	{string}	string	"Reposted"
+//	@Success		200	{string}	string	"Already reposted"
+//	@Failure		400	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/repost [put]
+func (app *application) repostHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if post.UserId == user.ID {
+		app.badRequestError(w, r, "you can't repost your own post")
+		return
+	}
+
+	status := http.StatusCreated
+
+	if err := app.store.Reposts.Repost(ctx, int64(post.ID), user.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrAlreadyReposted):
+			status = http.StatusOK
+		default:
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+	}
+
+	writeJSON(w, status, nil)
+}
+
+// unrepostHandler godoc
+//
+//	@Summary		Undoes a repost
+//	@Description	Removes the user's repost of a post. Removing a missing repost is a no-op.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"Post ID"
+//	@Success		204	{object}	string
+//	@Failure		400	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/repost [delete]
+func (app *application) unrepostHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := app.store.Reposts.Unrepost(ctx, int64(post.ID), user.ID); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// getSuggestionsHandler godoc
+//
+//	@Summary		Suggests users to follow
+//	@Description	Lists users the authenticated user may want to follow, ranked by mutual follows, shared tags and recent activity. Suggestions are recomputed periodically for recently active users.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit"
+//	@Success		200		{object}	[]store.Suggestion
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/suggestions [get]
+func (app *application) getSuggestionsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := min(10, app.config.suggestions.perUser)
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > app.config.suggestions.perUser {
+			app.badRequestError(w, r, "limit must be between 1 and "+strconv.Itoa(app.config.suggestions.perUser))
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	suggestions, err := app.store.Suggestions.GetByUserID(ctx, user.ID, limit)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, suggestions); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// computeSuggestions refreshes the precomputed follow suggestions of the
+// recently active users.
+func (app *application) computeSuggestions(ctx context.Context) error {
+	stored, err := app.store.Suggestions.Compute(ctx, app.config.suggestions.activityWindow, app.config.suggestions.perUser)
+	if err != nil {
+		return err
+	}
+
+	app.logger.Infow("follow suggestions computed", "suggestions", stored)
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"social/internal/entities"
+	"social/internal/store"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// normalizeTags merges the explicit tags with the #hashtags found in
+// content, case-folding and de-duplicating them.
+func normalizeTags(tags []string, content string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+
+	for _, t := range tags {
+		tag, ok := entities.NormalizeTag(t)
+		if !ok {
+			return nil, fmt.Errorf("invalid tag %q", t)
+		}
+
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+
+	for _, m := range entities.Hashtags(content) {
+		if !seen[m.Text] {
+			seen[m.Text] = true
+			normalized = append(normalized, m.Text)
+		}
+	}
+
+	return normalized, nil
+}
+
+// searchTagsHandler godoc
+//
+//	@Summary		Autocompletes tags
+//	@Description	Lists the most used tags starting with the given prefix
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			q		query		string	true	"Prefix"
+//	@Param			limit	query		int		false	"Limit"
+//	@Success		200		{object}	[]store.Tag
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/tags [get]
+func (app *application) searchTagsHandler(w http.ResponseWriter, r *http.Request) {
+	prefix, ok := entities.NormalizeTag(r.URL.Query().Get("q"))
+	if !ok {
+		app.badRequestError(w, r, "invalid tag prefix")
+		return
+	}
+
+	limit := 10
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > 50 {
+			app.badRequestError(w, r, "limit must be between 1 and 50")
+			return
+		}
+	}
+
+	tags, err := app.store.Tags.Search(r.Context(), prefix, limit)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, tags); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// getTagPostsHandler godoc
+//
+//	@Summary		Fetches the posts with a tag
+//	@Description	Fetches the posts with a tag, newest first by default
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			name	path		string	true	"Tag name"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Param			sort	query		string	false	"Sort"
+//	@Success		200		{object}	[]store.PostWithMetadata
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/tags/{name}/posts [get]
+func (app *application) getTagPostsHandler(w http.ResponseWriter, r *http.Request) {
+	name, ok := entities.NormalizeTag(chi.URLParam(r, "name"))
+	if !ok {
+		app.badRequestError(w, r, "invalid tag")
+		return
+	}
+
+	fq := store.PaginatedFieldQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "desc",
+	}
+
+	if err := fq.Parse(r); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	if _, err := app.store.Tags.GetByName(ctx, name); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	posts, err := app.store.Tags.GetPosts(ctx, name, user.ID, fq)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	for i := range posts {
+		if err := app.renderPostContent(ctx, &posts[i].Post); err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+	}
+
+	if err := writeJSON(w, http.StatusOK, posts); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
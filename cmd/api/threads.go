@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"social/internal/store"
+	"strconv"
+)
+
+type createThreadPayload struct {
+	// Posts are published in order, each replying to the previous one. Only
+	// the first post's in_reply_to_id is honored.
+	Posts []CreatePostPayload `json:"posts" validate:"required,min=2,max=25,dive"`
+}
+
+// CreateThread godoc
+//
+//	@Summary		Creates a thread
+//	@Description	Publishes an ordered series of posts, each replying to the one before it
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		createThreadPayload	true	"Thread payload"
+//	@Success		201		{array}		store.Post
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/thread [post]
+func (app *application) createThreadHandler(w http.ResponseWriter, r *http.Request) {
+	var payload createThreadPayload
+
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	for i := 1; i < len(payload.Posts); i++ {
+		payload.Posts[i].InReplyToID = nil
+	}
+
+	posts := make([]*store.Post, 0, len(payload.Posts))
+
+	for _, p := range payload.Posts {
+		post, ok := app.preparePost(w, r, p, user.ID)
+		if !ok {
+			return
+		}
+
+		posts = append(posts, post)
+	}
+
+	if err := app.store.Posts.CreateThread(ctx, posts); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	for _, post := range posts {
+		app.notifyMentions(ctx, int64(post.ID), nil, user.ID, post.Mentions, nil)
+
+		if err := app.renderPostContent(ctx, post); err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+	}
+
+	if err := writeJSON(w, http.StatusCreated, posts); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// GetThread godoc
+//
+//	@Summary		Fetches a post's conversation
+//	@Description	Returns the posts a post replies to and the replies below it
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int	true	"Post ID"
+//	@Param			limit	query		int	false	"Maximum number of replies"
+//	@Success		200		{object}	store.Thread
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/thread [get]
+func (app *application) getThreadHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	post, err := getPostFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	limit := 100
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > 500 {
+			app.badRequestError(w, r, "limit must be between 1 and 500")
+			return
+		}
+	}
+
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	thread, err := app.store.Posts.GetThread(ctx, int64(post.ID), user.ID, limit)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	thread.Post = post
+
+	for _, posts := range [][]store.Post{thread.Ancestors, thread.Descendants} {
+		for i := range posts {
+			if err := app.renderPostContent(ctx, &posts[i]); err != nil {
+				app.internalServerError(w, r, err.Error())
+				return
+			}
+		}
+	}
+
+	if err := app.renderPostContent(ctx, thread.Post); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, thread); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
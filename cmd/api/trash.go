@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"social/internal/store"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type trash struct {
+	Posts    []store.Post    `json:"posts"`
+	Comments []store.Comment `json:"comments"`
+}
+
+// getTrashHandler godoc
+//
+//	@Summary		Fetches a user's trash
+//	@Description	Lists the deleted posts and comments a user can still restore. Only the owner and admins can see it.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int	true	"User ID"
+//	@Param			limit	query		int	false	"Limit"
+//	@Param			offset	query		int	false	"Offset"
+//	@Success		200		{object}	trash
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/trash [get]
+func (app *application) getTrashHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil || userID < 1 {
+		app.badRequestError(w, r, "invalid user id")
+		return
+	}
+
+	fq := store.PaginatedFieldQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "desc",
+	}
+
+	if err := fq.Parse(r); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	allowed, err := app.canManageTrash(ctx, userID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if !allowed {
+		app.forbiddenError(w, r, "forbidden")
+		return
+	}
+
+	var t trash
+
+	t.Posts, err = app.store.Posts.GetDeletedByUserID(ctx, userID, fq)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	t.Comments, err = app.store.Comments.GetDeletedByUserID(ctx, userID, fq)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, t); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// restorePostHandler godoc
+//
+//	@Summary		Restores a deleted post
+//	@Description	Takes a post out of the trash. Only the owner and admins can restore it.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	int	true	"Post ID"
+//	@Success		204	"Post restored"
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/restore [post]
+func (app *application) restorePostHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	post, err := app.store.Posts.GetDeletedByID(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, "post is not in the trash")
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	allowed, err := app.canManageTrash(ctx, post.UserId)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if !allowed {
+		app.forbiddenError(w, r, "forbidden")
+		return
+	}
+
+	if err := app.store.Posts.Restore(ctx, post.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, "post is not in the trash")
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// restoreCommentHandler godoc
+//
+//	@Summary		Restores a deleted comment
+//	@Description	Takes a comment out of the trash while its post is still live. Only the author and admins can restore it.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path	int	true	"Post ID"
+//	@Param			commentID	path	int	true	"Comment ID"
+//	@Success		204			"Comment restored"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments/{commentID}/restore [post]
+func (app *application) restoreCommentHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	commentID, err := strconv.Atoi(chi.URLParam(r, "commentID"))
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	comment, err := app.store.Comments.GetDeletedByID(ctx, commentID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if comment == nil || comment.PostID != postID {
+		app.notFoundError(w, r, "comment is not in the trash")
+		return
+	}
+
+	allowed, err := app.canManageTrash(ctx, int64(comment.UserID))
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if !allowed {
+		app.forbiddenError(w, r, "forbidden")
+		return
+	}
+
+	if err := app.store.Comments.Restore(ctx, comment.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, "comment is not in the trash")
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// canManageTrash reports whether the current user may see or restore the
+// trash of ownerID: their own, or anyone's for admins.
+func (app *application) canManageTrash(ctx context.Context, ownerID int64) (bool, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if user.ID == ownerID {
+		return true, nil
+	}
+
+	return app.checkRolePrecedance(ctx, user, "admin")
+}
+
+// purgeTrash permanently removes posts and comments that have been in the
+// trash for longer than the retention period.
+func (app *application) purgeTrash(ctx context.Context) error {
+	before := time.Now().Add(-app.config.trash.retention)
+
+	posts, err := app.store.Posts.Purge(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	comments, err := app.store.Comments.Purge(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	if posts > 0 || comments > 0 {
+		app.logger.Infow("trash purged", "posts", posts, "comments", comments)
+	}
+
+	return nil
+}
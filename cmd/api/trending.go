@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"social/internal/store"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// parseWindows parses a comma separated list such as "1h,24h,7d". On top of
+// time.ParseDuration units it accepts "d" for days.
+func parseWindows(s string) (map[string]time.Duration, error) {
+	windows := map[string]time.Duration{}
+
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+
+		var d time.Duration
+		var err error
+
+		if days, ok := strings.CutSuffix(name, "d"); ok {
+			var n int
+			n, err = strconv.Atoi(days)
+			d = time.Duration(n) * 24 * time.Hour
+		} else {
+			d, err = time.ParseDuration(name)
+		}
+
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid trending window %q", name)
+		}
+
+		windows[name] = d
+	}
+
+	return windows, nil
+}
+
+// computeTrending recomputes the ranking of every configured window and
+// stores it in Redis when enabled, Postgres otherwise.
+func (app *application) computeTrending(ctx context.Context) error {
+	for name, window := range app.config.trending.windows {
+		tags, err := app.store.Trending.ComputeTags(ctx, window, app.config.trending.limit)
+		if err != nil {
+			return err
+		}
+
+		posts, err := app.store.Trending.ComputePosts(ctx, window, app.config.trending.limit)
+		if err != nil {
+			return err
+		}
+
+		if !app.config.redisCfg.enabled {
+			if err := app.store.Trending.SaveSnapshot(ctx, name, tags, posts); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := app.cacheStorage.Trending.SetTags(ctx, name, tags); err != nil {
+			return err
+		}
+
+		if err := app.cacheStorage.Trending.SetPosts(ctx, name, posts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseTrendingQuery reads the window and limit query parameters.
+func (app *application) parseTrendingQuery(r *http.Request) (string, int, error) {
+	qs := r.URL.Query()
+
+	window := qs.Get("window")
+	if window == "" {
+		window = app.config.trending.defaultWindow
+	}
+
+	if _, ok := app.config.trending.windows[window]; !ok {
+		return "", 0, fmt.Errorf("unknown window %q", window)
+	}
+
+	limit := 10
+
+	if l := qs.Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > app.config.trending.limit {
+			return "", 0, fmt.Errorf("limit must be between 1 and %d", app.config.trending.limit)
+		}
+	}
+
+	return window, limit, nil
+}
+
+// getTrendingTagsHandler godoc
+//
+//	@Summary		Fetches trending tags
+//	@Description	Fetches the tags with the most recent activity in a time window
+//	@Tags			trending
+//	@Accept			json
+//	@Produce		json
+//	@Param			window	query		string	false	"Window, e.g. 1h, 24h or 7d"
+//	@Param			limit	query		int		false	"Limit"
+//	@Success		200		{object}	[]store.TrendingTag
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/trending/tags [get]
+func (app *application) getTrendingTagsHandler(w http.ResponseWriter, r *http.Request) {
+	window, limit, err := app.parseTrendingQuery(r)
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	var tags []store.TrendingTag
+
+	if app.config.redisCfg.enabled {
+		tags, err = app.cacheStorage.Trending.GetTags(ctx, window)
+		if err == redis.Nil {
+			tags, err = []store.TrendingTag{}, nil
+		}
+
+		if len(tags) > limit {
+			tags = tags[:limit]
+		}
+	} else {
+		tags, err = app.store.Trending.GetTags(ctx, window, limit)
+	}
+
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, tags); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
+
+// getTrendingPostsHandler godoc
+//
+//	@Summary		Fetches trending posts
+//	@Description	Fetches the posts with the most recent activity in a time window
+//	@Tags			trending
+//	@Accept			json
+//	@Produce		json
+//	@Param			window	query		string	false	"Window, e.g. 1h, 24h or 7d"
+//	@Param			limit	query		int		false	"Limit"
+//	@Success		200		{object}	[]store.TrendingPost
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/trending/posts [get]
+func (app *application) getTrendingPostsHandler(w http.ResponseWriter, r *http.Request) {
+	window, limit, err := app.parseTrendingQuery(r)
+	if err != nil {
+		app.badRequestError(w, r, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	// The whole ranking is read, and only cut down to limit once the posts
+	// the viewer can't see are gone.
+	var posts []store.TrendingPost
+
+	if app.config.redisCfg.enabled {
+		posts, err = app.cacheStorage.Trending.GetPosts(ctx, window)
+		if err == redis.Nil {
+			posts, err = []store.TrendingPost{}, nil
+		}
+	} else {
+		posts, err = app.store.Trending.GetPosts(ctx, window, app.config.trending.limit)
+	}
+
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	// The ranking is shared by everyone and may be older than the latest
+	// deletions, so deleted posts and those the viewer may not see are
+	// dropped here.
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	ids := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, int64(post.ID))
+	}
+
+	visible, err := app.store.Posts.FilterVisible(ctx, user.ID, ids)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	posts = slices.DeleteFunc(posts, func(post store.TrendingPost) bool {
+		return !visible[int64(post.ID)]
+	})
+
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+
+	for i := range posts {
+		if err := app.renderPostContent(ctx, &posts[i].Post); err != nil {
+			app.internalServerError(w, r, err.Error())
+			return
+		}
+	}
+
+	if err := writeJSON(w, http.StatusOK, posts); err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"social/internal/events"
 	"social/internal/store"
 	"strconv"
 
@@ -13,17 +14,22 @@ import (
 
 type userContextKey string
 
+type userProfile struct {
+	*store.User
+	*store.UserStats
+}
+
 const userContext userContextKey = "user"
 
 // GetUser godoc
 //
 //	@Summary		Fetches a user profile
-//	@Description	Fetches a user profile by ID
+//	@Description	Fetches a user profile by ID, with follower, following and post counts
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
 //	@Param			userID	path		int	true	"User ID"
-//	@Success		200		{object}	store.User
+//	@Success		200		{object}	userProfile
 //	@Failure		400		{object}	error
 //	@Failure		404		{object}	error
 //	@Failure		500		{object}	error
@@ -66,13 +72,53 @@ func (app *application) getUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, user)
+	viewer, err := getUserFromContext(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	hidden, err := app.hiddenByBlock(ctx, viewer.ID, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if hidden {
+		app.notFoundError(w, r, store.ErrNotFound.Error())
+		return
+	}
+
+	stats, err := app.store.Users.GetStats(ctx, user.ID, viewer.ID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userProfile{User: user, UserStats: stats})
 }
 
 type FollowUser struct {
 	UserID int64 `json:"user_id"`
 }
 
+// FollowUser godoc
+//
+//	@Summary		Follows a user
+//	@Description	Follows a user. Following a private account sends a follow request instead, which the account has to approve.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int	true	"User ID"
+//	@Success		201		{object}	string
+//	@Success		202		{object}	store.FollowRequest
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/follow [put]
 func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request) {
 	followedID, err := strconv.Atoi(chi.URLParam(r, "userID"))
 	if err != nil {
@@ -88,20 +134,107 @@ func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request
 	// Follow the user
 	ctx := r.Context()
 
+	followed, err := app.store.Users.GetById(ctx, followedID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundError(w, r, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	if followed.ID == followerUser.ID {
+		app.badRequestError(w, r, store.ErrSelfFollow.Error())
+		return
+	}
+
+	blocked, err := app.store.Blocks.IsBlocked(ctx, followerUser.ID, followed.ID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if blocked {
+		app.forbiddenError(w, r, "you can't follow this user")
+		return
+	}
+
+	if followed.IsPrivate {
+		app.requestFollow(w, r, followed, followerUser)
+		return
+	}
+
 	if err := app.store.Followers.Follow(ctx, followerUser.ID, int64(followedID)); err != nil {
 		switch {
 		case errors.Is(err, store.ErrAlreadyFollowing):
 			writeJSONError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, store.ErrSelfFollow):
+			app.badRequestError(w, r, err.Error())
+		case errors.Is(err, store.ErrUserNotFound):
+			app.notFoundError(w, r, err.Error())
 		default:
 			app.internalServerError(w, r, err.Error())
 		}
 		return
 	}
 
+	app.publishFollow(ctx, followerUser.ID, followed.ID)
+
 	writeJSON(w, http.StatusCreated, nil)
 
 }
 
+// publishFollow announces that followerID started following userID. It is
+// called for every follow created, however it came about.
+func (app *application) publishFollow(ctx context.Context, followerID, userID int64) {
+	event := events.Event{
+		Type:    events.TypeFollow,
+		ActorID: followerID,
+		UserID:  userID,
+	}
+
+	if err := app.events.Publish(ctx, event); err != nil {
+		app.logger.Warnw("Failed to publish follow event", "error", err)
+	}
+}
+
+// requestFollow records requester's pending request to follow a private
+// account.
+func (app *application) requestFollow(w http.ResponseWriter, r *http.Request, followed, requester *store.User) {
+	ctx := r.Context()
+
+	following, err := app.store.Followers.IsFollowing(ctx, requester.ID, followed.ID)
+	if err != nil {
+		app.internalServerError(w, r, err.Error())
+		return
+	}
+
+	if following {
+		writeJSONError(w, http.StatusConflict, store.ErrAlreadyFollowing.Error())
+		return
+	}
+
+	request := &store.FollowRequest{UserID: followed.ID, RequesterID: requester.ID}
+
+	if err := app.store.FollowRequests.Create(ctx, request); err != nil {
+		switch {
+		case errors.Is(err, store.ErrFollowRequestExists):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		default:
+			app.internalServerError(w, r, err.Error())
+		}
+		return
+	}
+
+	request.Requester = store.User{ID: requester.ID, Username: requester.Username}
+
+	if err := writeJSON(w, http.StatusAccepted, request); err != nil {
+		app.internalServerError(w, r, err.Error())
+	}
+}
+
 func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Request) {
 	unfollowedID, err := strconv.Atoi(chi.URLParam(r, "userID"))
 	if err != nil {
@@ -0,0 +1,4 @@
+ALTER TABLE
+  posts
+ADD
+  COLUMN IF NOT EXISTS content_format VARCHAR(20) NOT NULL DEFAULT 'plain';
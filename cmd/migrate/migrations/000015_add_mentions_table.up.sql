@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS mentions (
+  id bigserial PRIMARY KEY,
+  post_id bigint NOT NULL,
+  comment_id bigint,
+  author_id bigint NOT NULL,
+  user_id bigint NOT NULL,
+  offset_chars int NOT NULL,
+  length_chars int NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+  FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+  FOREIGN KEY (comment_id) REFERENCES comments (id) ON DELETE CASCADE,
+  FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_mentions_user_id
+ON mentions (user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_mentions_post_id
+ON mentions (post_id);
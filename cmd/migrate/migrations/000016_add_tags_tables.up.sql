@@ -0,0 +1,53 @@
+CREATE TABLE IF NOT EXISTS tags (
+  id bigserial PRIMARY KEY,
+  name VARCHAR(100) NOT NULL UNIQUE,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT tags_name_lower CHECK (name = lower(name))
+);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+  post_id bigint NOT NULL,
+  tag_id bigint NOT NULL,
+
+  PRIMARY KEY (post_id, tag_id),
+  FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+  FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+);
+
+-- Index for prefix autocompletion on tag names
+CREATE INDEX IF NOT EXISTS idx_tags_name_prefix
+ON tags (name text_pattern_ops);
+
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag_id
+ON post_tags (tag_id, post_id);
+
+-- Normalize the existing free-form tags and backfill the join table
+UPDATE
+  posts
+SET
+  tags = ARRAY(
+    SELECT DISTINCT lower(btrim(t, ' #'))
+    FROM unnest(tags) AS t
+    WHERE btrim(t, ' #') <> ''
+  )
+WHERE
+  tags IS NOT NULL;
+
+INSERT INTO
+  tags (name)
+SELECT DISTINCT
+  unnest(tags)
+FROM
+  posts
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO
+  post_tags (post_id, tag_id)
+SELECT
+  p.id,
+  t.id
+FROM
+  posts p
+  JOIN tags t ON t.name = ANY(p.tags)
+ON CONFLICT DO NOTHING;
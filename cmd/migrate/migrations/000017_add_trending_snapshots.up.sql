@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS trending_tags (
+  time_window VARCHAR(20) NOT NULL,
+  rank int NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  score double precision NOT NULL,
+  posts_count int NOT NULL,
+  computed_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (time_window, rank)
+);
+
+CREATE TABLE IF NOT EXISTS trending_posts (
+  time_window VARCHAR(20) NOT NULL,
+  rank int NOT NULL,
+  post_id bigint NOT NULL,
+  score double precision NOT NULL,
+  computed_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (time_window, rank),
+  FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+-- Indexes for the windowed activity scans
+CREATE INDEX IF NOT EXISTS idx_posts_created_at
+ON posts (created_at);
+
+CREATE INDEX IF NOT EXISTS idx_comments_created_at
+ON comments (created_at);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS post_reactions (
+  post_id bigint NOT NULL,
+  user_id bigint NOT NULL,
+  kind VARCHAR(20) NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (post_id, user_id, kind),
+  FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT post_reactions_kind CHECK (kind IN ('like', 'love', 'haha', 'wow', 'sad', 'angry'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_reactions_user_id
+ON post_reactions (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_post_reactions_created_at
+ON post_reactions (created_at);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS reposts;
+
+DROP INDEX IF EXISTS idx_posts_quote_of_id;
+
+ALTER TABLE
+  posts DROP COLUMN IF EXISTS quote_of_id;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS bookmark_collections (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT bookmark_collections_user_name UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+  user_id bigint NOT NULL,
+  post_id bigint NOT NULL,
+  collection_id bigint,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (user_id, post_id),
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+  FOREIGN KEY (collection_id) REFERENCES bookmark_collections (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookmarks_user_created_at
+ON bookmarks (user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_bookmarks_collection_id
+ON bookmarks (collection_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_posts_in_reply_to_id;
+
+ALTER TABLE
+  posts DROP COLUMN IF EXISTS in_reply_to_id;
@@ -0,0 +1,7 @@
+ALTER TABLE
+  posts
+ADD
+  COLUMN IF NOT EXISTS in_reply_to_id bigint REFERENCES posts (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_posts_in_reply_to_id
+ON posts (in_reply_to_id);
@@ -0,0 +1,15 @@
+ALTER TABLE
+  posts
+ADD
+  COLUMN IF NOT EXISTS deleted_at timestamp(0) with time zone;
+
+ALTER TABLE
+  comments
+ADD
+  COLUMN IF NOT EXISTS deleted_at timestamp(0) with time zone;
+
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at
+ON posts (user_id, deleted_at DESC) WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at
+ON comments (user_id, deleted_at DESC) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE
+  comments DROP COLUMN IF EXISTS hidden_at;
+
+ALTER TABLE
+  comments DROP COLUMN IF EXISTS edited_at;
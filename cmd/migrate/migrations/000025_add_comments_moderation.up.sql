@@ -0,0 +1,9 @@
+ALTER TABLE
+  comments
+ADD
+  COLUMN IF NOT EXISTS edited_at timestamp(0) with time zone;
+
+ALTER TABLE
+  comments
+ADD
+  COLUMN IF NOT EXISTS hidden_at timestamp(0) with time zone;
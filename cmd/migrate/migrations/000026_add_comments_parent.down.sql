@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_comments_post_id_created_at;
+
+DROP INDEX IF EXISTS idx_comments_parent_id;
+
+ALTER TABLE
+  comments DROP COLUMN IF EXISTS depth;
+
+ALTER TABLE
+  comments DROP COLUMN IF EXISTS parent_id;
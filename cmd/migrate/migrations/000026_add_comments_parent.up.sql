@@ -0,0 +1,15 @@
+ALTER TABLE
+  comments
+ADD
+  COLUMN IF NOT EXISTS parent_id bigint REFERENCES comments (id) ON DELETE CASCADE;
+
+ALTER TABLE
+  comments
+ADD
+  COLUMN IF NOT EXISTS depth int NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id
+ON comments (parent_id, created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_comments_post_id_created_at
+ON comments (post_id, created_at DESC, id DESC) WHERE parent_id IS NULL;
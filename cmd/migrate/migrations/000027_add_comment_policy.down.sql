@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_comments_pending;
+
+ALTER TABLE
+  comments DROP COLUMN IF EXISTS status;
+
+ALTER TABLE
+  posts DROP COLUMN IF EXISTS comment_policy;
@@ -0,0 +1,14 @@
+ALTER TABLE
+  posts
+ADD
+  COLUMN IF NOT EXISTS comment_policy varchar(20) NOT NULL DEFAULT 'everyone'
+  CHECK (comment_policy IN ('everyone', 'followers', 'mentioned', 'nobody', 'approval'));
+
+ALTER TABLE
+  comments
+ADD
+  COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'approved'
+  CHECK (status IN ('pending', 'approved'));
+
+CREATE INDEX IF NOT EXISTS idx_comments_pending
+ON comments (post_id, created_at) WHERE status = 'pending';
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_followers_user_id_created_at;
+
+DROP INDEX IF EXISTS idx_followers_follower_id;
+
+DROP TRIGGER IF EXISTS posts_user_stats ON posts;
+
+DROP FUNCTION IF EXISTS user_stats_count_post();
+
+DROP TRIGGER IF EXISTS followers_user_stats ON followers;
+
+DROP FUNCTION IF EXISTS user_stats_count_follow();
+
+DROP TABLE IF EXISTS user_stats;
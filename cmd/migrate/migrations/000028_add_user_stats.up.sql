@@ -0,0 +1,75 @@
+CREATE TABLE IF NOT EXISTS user_stats (
+  user_id bigint PRIMARY KEY,
+  followers_count bigint NOT NULL DEFAULT 0,
+  following_count bigint NOT NULL DEFAULT 0,
+  posts_count bigint NOT NULL DEFAULT 0,
+
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE OR REPLACE FUNCTION user_stats_count_follow() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'INSERT' THEN
+    INSERT INTO user_stats (user_id, followers_count) VALUES (NEW.user_id, 1)
+    ON CONFLICT (user_id) DO UPDATE SET followers_count = user_stats.followers_count + 1;
+
+    INSERT INTO user_stats (user_id, following_count) VALUES (NEW.follower_id, 1)
+    ON CONFLICT (user_id) DO UPDATE SET following_count = user_stats.following_count + 1;
+  ELSE
+    UPDATE user_stats SET followers_count = followers_count - 1 WHERE user_id = OLD.user_id;
+    UPDATE user_stats SET following_count = following_count - 1 WHERE user_id = OLD.follower_id;
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER followers_user_stats
+AFTER INSERT OR DELETE ON followers
+FOR EACH ROW EXECUTE FUNCTION user_stats_count_follow();
+
+-- Soft-deleted posts don't count, so deleting and restoring a post moves
+-- the counter as well.
+CREATE OR REPLACE FUNCTION user_stats_count_post() RETURNS trigger AS $$
+DECLARE
+  delta int := 0;
+  owner bigint;
+BEGIN
+  IF TG_OP = 'INSERT' THEN
+    owner := NEW.user_id;
+    IF NEW.deleted_at IS NULL THEN delta := 1; END IF;
+  ELSIF TG_OP = 'DELETE' THEN
+    owner := OLD.user_id;
+    IF OLD.deleted_at IS NULL THEN delta := -1; END IF;
+  ELSE
+    owner := NEW.user_id;
+    IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN delta := -1; END IF;
+    IF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN delta := 1; END IF;
+  END IF;
+
+  IF delta <> 0 THEN
+    INSERT INTO user_stats (user_id, posts_count) VALUES (owner, GREATEST(delta, 0))
+    ON CONFLICT (user_id) DO UPDATE SET posts_count = user_stats.posts_count + delta;
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_user_stats
+AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON posts
+FOR EACH ROW EXECUTE FUNCTION user_stats_count_post();
+
+INSERT INTO user_stats (user_id, followers_count, following_count, posts_count)
+SELECT u.id,
+  (SELECT COUNT(*) FROM followers f WHERE f.user_id = u.id),
+  (SELECT COUNT(*) FROM followers f WHERE f.follower_id = u.id),
+  (SELECT COUNT(*) FROM posts p WHERE p.user_id = u.id AND p.deleted_at IS NULL)
+FROM users u
+ON CONFLICT (user_id) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_followers_follower_id
+ON followers (follower_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_followers_user_id_created_at
+ON followers (user_id, created_at DESC);
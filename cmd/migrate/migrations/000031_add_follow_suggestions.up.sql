@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS follow_suggestions (
+  user_id bigint NOT NULL,
+  candidate_id bigint NOT NULL,
+  score double precision NOT NULL,
+  mutual_count int NOT NULL DEFAULT 0,
+  shared_tags_count int NOT NULL DEFAULT 0,
+  recent_posts_count int NOT NULL DEFAULT 0,
+  computed_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (user_id, candidate_id),
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  FOREIGN KEY (candidate_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_follow_suggestions_user_id_score
+ON follow_suggestions (user_id, score DESC);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS follow_import_rows;
+
+DROP TABLE IF EXISTS follow_imports;
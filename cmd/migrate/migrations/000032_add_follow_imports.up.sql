@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS follow_imports (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL,
+  status varchar(20) NOT NULL DEFAULT 'pending'
+  CHECK (status IN ('pending', 'running', 'completed')),
+  total int NOT NULL DEFAULT 0,
+  processed int NOT NULL DEFAULT 0,
+  not_before timestamp(0) with time zone,
+  claimed_at timestamp(0) with time zone,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  completed_at timestamp(0) with time zone,
+
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_follow_imports_unfinished
+ON follow_imports (created_at) WHERE status <> 'completed';
+
+CREATE TABLE IF NOT EXISTS follow_import_rows (
+  import_id bigint NOT NULL,
+  line int NOT NULL,
+  identifier varchar(255) NOT NULL,
+  status varchar(20) NOT NULL DEFAULT 'pending',
+  user_id bigint,
+
+  PRIMARY KEY (import_id, line),
+  FOREIGN KEY (import_id) REFERENCES follow_imports (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
@@ -0,0 +1,13 @@
+ALTER TABLE
+  posts
+DROP
+  COLUMN IF EXISTS audience_list_id;
+
+ALTER TABLE
+  posts
+DROP
+  COLUMN IF EXISTS audience;
+
+DROP TABLE IF EXISTS audience_list_members;
+
+DROP TABLE IF EXISTS audience_lists;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS audience_lists (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL,
+  name varchar(100) NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT audience_lists_user_name_unique UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS audience_list_members (
+  list_id bigint NOT NULL,
+  user_id bigint NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (list_id, user_id),
+  FOREIGN KEY (list_id) REFERENCES audience_lists (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- A post whose list is deleted keeps its 'list' audience, so it stays
+-- visible to its author only rather than becoming public.
+ALTER TABLE
+  posts
+ADD
+  COLUMN IF NOT EXISTS audience varchar(20) NOT NULL DEFAULT 'public'
+  CHECK (audience IN ('public', 'list'));
+
+ALTER TABLE
+  posts
+ADD
+  COLUMN IF NOT EXISTS audience_list_id bigint REFERENCES audience_lists (id) ON DELETE SET NULL;
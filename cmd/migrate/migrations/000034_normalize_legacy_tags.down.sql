@@ -0,0 +1 @@
+-- The characters dropped from legacy tags can't be restored.
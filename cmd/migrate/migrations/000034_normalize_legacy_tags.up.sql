@@ -0,0 +1,40 @@
+-- Tags written before tags were validated may hold characters other than
+-- letters, digits and underscores, which the API now rejects whenever the
+-- post is saved again. Drop those characters, as NormalizeTag would require,
+-- and rebuild the join rows of the affected tags.
+UPDATE
+  posts
+SET
+  tags = ARRAY(
+    SELECT DISTINCT left(regexp_replace(t, '[^[:alnum:]_]', '', 'g'), 100)
+    FROM unnest(tags) AS t
+    WHERE regexp_replace(t, '[^[:alnum:]_]', '', 'g') <> ''
+  )
+WHERE
+  EXISTS (
+    SELECT 1 FROM unnest(tags) AS t WHERE t !~ '^[[:alnum:]_]{1,100}$'
+  );
+
+INSERT INTO
+  tags (name)
+SELECT DISTINCT
+  unnest(tags)
+FROM
+  posts
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO
+  post_tags (post_id, tag_id)
+SELECT
+  p.id,
+  t.id
+FROM
+  posts p
+  JOIN tags t ON t.name = ANY(p.tags)
+ON CONFLICT DO NOTHING;
+
+-- Removing the old tags also removes their join rows.
+DELETE FROM
+  tags
+WHERE
+  name !~ '^[[:alnum:]_]{1,100}$';
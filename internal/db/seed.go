@@ -102,9 +102,8 @@ func generatePosts(users []store.User, num int) []store.Post {
 
 func generateRandomTags(r *rand.Rand, num int) []string {
 	selectedTags := make([]string, 0, num)
-	for i := 0; i < num; i++ {
-		tag := tags[r.Intn(len(tags))]
-		selectedTags = append(selectedTags, tag)
+	for _, i := range r.Perm(len(tags))[:num] {
+		selectedTags = append(selectedTags, tags[i])
 	}
 	return selectedTags
 }
@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Match is a single entity found in user content. Offset and Length are
+// counted in characters (runes), not bytes, so clients can slice the
+// original string directly.
+type Match struct {
+	Text   string
+	Offset int
+	Length int
+}
+
+const MaxTagLength = 100
+
+var (
+	mentionRegex = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_]{3,40})\b`)
+	hashtagRegex = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_#&])#([\p{L}\p{N}_]+)`)
+	tagRegex     = regexp.MustCompile(`^[\p{L}\p{N}_]+$`)
+)
+
+// Mentions returns every @username in content. Text holds the username
+// without the leading "@"; Offset and Length cover the "@" as well.
+func Mentions(content string) []Match {
+	return find(mentionRegex, content)
+}
+
+// Hashtags returns every #hashtag in content. Text holds the canonical tag
+// name; purely numeric tags such as "#1" are ignored.
+func Hashtags(content string) []Match {
+	matches := []Match{}
+
+	for _, m := range find(hashtagRegex, content) {
+		tag, ok := NormalizeTag(m.Text)
+		if !ok || !strings.ContainsFunc(tag, unicode.IsLetter) {
+			continue
+		}
+
+		m.Text = tag
+		matches = append(matches, m)
+	}
+
+	return matches
+}
+
+// NormalizeTag case-folds a tag and strips a leading "#". It reports false
+// when the result is empty, too long or contains characters other than
+// letters, digits and underscores.
+func NormalizeTag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength || !tagRegex.MatchString(tag) {
+		return "", false
+	}
+
+	return tag, true
+}
+
+func find(re *regexp.Regexp, content string) []Match {
+	matches := []Match{}
+
+	for _, loc := range re.FindAllStringSubmatchIndex(content, -1) {
+		// loc[2]:loc[3] is the captured name; the sigil sits right before it.
+		start, end := loc[2]-1, loc[3]
+
+		matches = append(matches, Match{
+			Text:   content[loc[2]:loc[3]],
+			Offset: utf8.RuneCountInString(content[:start]),
+			Length: utf8.RuneCountInString(content[start:end]),
+		})
+	}
+
+	return matches
+}
@@ -0,0 +1,22 @@
+package events
+
+import "context"
+
+const (
+	TypeMention = "mention"
+	TypeFollow  = "follow"
+)
+
+// Event describes something a user may want to be notified about. ActorID
+// is who caused it and UserID is who it is addressed to.
+type Event struct {
+	Type      string `json:"type"`
+	ActorID   int64  `json:"actor_id"`
+	UserID    int64  `json:"user_id"`
+	PostID    int64  `json:"post_id,omitempty"`
+	CommentID int64  `json:"comment_id,omitempty"`
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
@@ -0,0 +1,29 @@
+package events
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// LogPublisher records events in the application log until a real
+// notification channel is wired in.
+type LogPublisher struct {
+	logger *zap.SugaredLogger
+}
+
+func NewLogPublisher(logger *zap.SugaredLogger) *LogPublisher {
+	return &LogPublisher{logger}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, e Event) error {
+	p.logger.Infow("event published",
+		"type", e.Type,
+		"actor_id", e.ActorID,
+		"user_id", e.UserID,
+		"post_id", e.PostID,
+		"comment_id", e.CommentID,
+	)
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const (
+	PostAudiencePublic = "public"
+	PostAudienceList   = "list"
+)
+
+// AudienceList is a user-owned group of users that posts can be shared
+// with. Only its owner can see it.
+type AudienceList struct {
+	ID           int64  `json:"id"`
+	UserID       int64  `json:"user_id"`
+	Name         string `json:"name"`
+	CreatedAt    string `json:"created_at"`
+	MembersCount int    `json:"members_count"`
+}
+
+type AudienceListsStore struct {
+	db *sql.DB
+}
+
+func (s *AudienceListsStore) Create(ctx context.Context, list *AudienceList) error {
+	query := `INSERT INTO audience_lists (user_id, name) VALUES ($1, $2) RETURNING id, created_at`
+
+	err := s.db.QueryRowContext(ctx, query, list.UserID, list.Name).Scan(
+		&list.ID,
+		&list.CreatedAt,
+	)
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrDuplicateAudienceList
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByID returns the list only if it belongs to userID.
+func (s *AudienceListsStore) GetByID(ctx context.Context, id, userID int64) (*AudienceList, error) {
+	query := `
+		SELECT al.id, al.user_id, al.name, al.created_at, COUNT(alm.user_id)
+		FROM audience_lists al
+		LEFT JOIN audience_list_members alm ON alm.list_id = al.id
+		WHERE al.id = $1 AND al.user_id = $2
+		GROUP BY al.id`
+
+	list := &AudienceList{}
+
+	err := s.db.QueryRowContext(ctx, query, id, userID).Scan(
+		&list.ID,
+		&list.UserID,
+		&list.Name,
+		&list.CreatedAt,
+		&list.MembersCount,
+	)
+
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+func (s *AudienceListsStore) GetByUserID(ctx context.Context, userID int64) ([]AudienceList, error) {
+	query := `
+		SELECT al.id, al.user_id, al.name, al.created_at, COUNT(alm.user_id)
+		FROM audience_lists al
+		LEFT JOIN audience_list_members alm ON alm.list_id = al.id
+		WHERE al.user_id = $1
+		GROUP BY al.id
+		ORDER BY al.name`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	lists := []AudienceList{}
+
+	for rows.Next() {
+		list := AudienceList{}
+
+		err := rows.Scan(
+			&list.ID,
+			&list.UserID,
+			&list.Name,
+			&list.CreatedAt,
+			&list.MembersCount,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, list)
+	}
+
+	return lists, rows.Err()
+}
+
+func (s *AudienceListsStore) Rename(ctx context.Context, list *AudienceList) error {
+	query := `UPDATE audience_lists SET name = $1 WHERE id = $2 AND user_id = $3`
+
+	res, err := s.db.ExecContext(ctx, query, list.Name, list.ID, list.UserID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrDuplicateAudienceList
+		}
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes the list. Posts shared with it stay visible to their
+// author only.
+func (s *AudienceListsStore) Delete(ctx context.Context, id, userID int64) error {
+	query := `DELETE FROM audience_lists WHERE id = $1 AND user_id = $2`
+
+	return execAffectingRow(ctx, s.db, query, id, userID)
+}
+
+func (s *AudienceListsStore) AddMember(ctx context.Context, listID, userID int64) error {
+	query := `
+		INSERT INTO audience_list_members (list_id, user_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+
+	_, err := s.db.ExecContext(ctx, query, listID, userID)
+
+	return err
+}
+
+func (s *AudienceListsStore) RemoveMember(ctx context.Context, listID, userID int64) error {
+	query := `DELETE FROM audience_list_members WHERE list_id = $1 AND user_id = $2`
+
+	return execAffectingRow(ctx, s.db, query, listID, userID)
+}
+
+// GetMembers returns the list's members, most recently added first.
+func (s *AudienceListsStore) GetMembers(ctx context.Context, listID int64, cursor *Cursor, limit int) ([]RelatedUser, error) {
+	query := `
+		SELECT u.id, u.username, alm.created_at
+		FROM audience_list_members alm
+		JOIN users u ON u.id = alm.user_id
+		WHERE alm.list_id = $1`
+
+	args := []any{listID, limit}
+
+	if cursor != nil {
+		query += ` AND (alm.created_at, u.id) < ($3, $4)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+
+	query += `
+		ORDER BY alm.created_at DESC, u.id DESC
+		LIMIT $2`
+
+	return queryRelatedUsers(ctx, s.db, query, args...)
+}
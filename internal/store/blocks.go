@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// RelatedUser is a user in one of the authenticated user's lists, such as
+// the users they blocked or muted, with when they were added.
+type RelatedUser struct {
+	User      User   `json:"user"`
+	CreatedAt string `json:"created_at"`
+}
+
+type BlocksStore struct {
+	db *sql.DB
+}
+
+// Block records that blockerID blocked blockedID and drops every follow and
+// follow request between the two, in either direction.
+func (s *BlocksStore) Block(ctx context.Context, blockerID, blockedID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2)`
+
+		if _, err := tx.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				return ErrAlreadyBlocked
+			}
+			return err
+		}
+
+		query = `
+			DELETE FROM followers
+			WHERE (user_id = $1 AND follower_id = $2) OR (user_id = $2 AND follower_id = $1)`
+
+		if _, err := tx.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+			return err
+		}
+
+		query = `
+			DELETE FROM follow_requests
+			WHERE (user_id = $1 AND requester_id = $2) OR (user_id = $2 AND requester_id = $1)`
+
+		_, err := tx.ExecContext(ctx, query, blockerID, blockedID)
+
+		return err
+	})
+}
+
+func (s *BlocksStore) Unblock(ctx context.Context, blockerID, blockedID int64) error {
+	query := `DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2`
+
+	return execAffectingRow(ctx, s.db, query, blockerID, blockedID)
+}
+
+// IsBlocked reports whether either user has blocked the other.
+func (s *BlocksStore) IsBlocked(ctx context.Context, userID, otherID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)`
+
+	var blocked bool
+
+	err := s.db.QueryRowContext(ctx, query, userID, otherID).Scan(&blocked)
+
+	return blocked, err
+}
+
+// GetBlocked returns the users blockerID has blocked, most recent first.
+func (s *BlocksStore) GetBlocked(ctx context.Context, blockerID int64, cursor *Cursor, limit int) ([]RelatedUser, error) {
+	query := `
+		SELECT u.id, u.username, b.created_at
+		FROM blocks b
+		JOIN users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = $1`
+
+	args := []any{blockerID, limit}
+
+	if cursor != nil {
+		query += ` AND (b.created_at, u.id) < ($3, $4)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+
+	query += `
+		ORDER BY b.created_at DESC, u.id DESC
+		LIMIT $2`
+
+	return queryRelatedUsers(ctx, s.db, query, args...)
+}
+
+// queryRelatedUsers runs a query selecting a user's id and username and the
+// time they were added to the list.
+func queryRelatedUsers(ctx context.Context, db *sql.DB, query string, args ...any) ([]RelatedUser, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	users := []RelatedUser{}
+
+	for rows.Next() {
+		var related RelatedUser
+
+		if err := rows.Scan(&related.User.ID, &related.User.Username, &related.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		users = append(users, related)
+	}
+
+	return users, rows.Err()
+}
+
+// execAffectingRow runs a statement that should affect a row, returning
+// ErrNotFound when it didn't.
+func execAffectingRow(ctx context.Context, db *sql.DB, query string, args ...any) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
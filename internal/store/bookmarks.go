@@ -0,0 +1,267 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type Bookmark struct {
+	UserID       int64  `json:"user_id"`
+	PostID       int64  `json:"post_id"`
+	CollectionID *int64 `json:"collection_id"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type BookmarkCollection struct {
+	ID             int64  `json:"id"`
+	UserID         int64  `json:"user_id"`
+	Name           string `json:"name"`
+	CreatedAt      string `json:"created_at"`
+	BookmarksCount int    `json:"bookmarks_count"`
+}
+
+type BookmarkedPost struct {
+	PostWithMetadata
+
+	CollectionID *int64 `json:"collection_id"`
+	BookmarkedAt string `json:"bookmarked_at"`
+}
+
+type BookmarksStore struct {
+	db *sql.DB
+}
+
+// Save bookmarks a post, or moves an existing bookmark to another collection.
+func (s *BookmarksStore) Save(ctx context.Context, bookmark *Bookmark) error {
+	query := `
+		INSERT INTO bookmarks (user_id, post_id, collection_id) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, post_id) DO UPDATE SET collection_id = EXCLUDED.collection_id
+		RETURNING created_at`
+
+	return s.db.QueryRowContext(
+		ctx,
+		query,
+		bookmark.UserID,
+		bookmark.PostID,
+		bookmark.CollectionID,
+	).Scan(&bookmark.CreatedAt)
+}
+
+func (s *BookmarksStore) Remove(ctx context.Context, userID, postID int64) error {
+	query := `DELETE FROM bookmarks WHERE user_id = $1 AND post_id = $2`
+
+	_, err := s.db.ExecContext(ctx, query, userID, postID)
+
+	return err
+}
+
+// GetByUserID lists a user's bookmarked posts, optionally only those in one
+// collection, filtered by the search and tags of fq.
+func (s *BookmarksStore) GetByUserID(ctx context.Context, userID int64, collectionID *int64, fq PaginatedFieldQuery) ([]BookmarkedPost, error) {
+	query := `
+		SELECT p.id, p.user_id, p.title, p.content, p.content_format, p.created_at, p.version, p.tags,
+			u.username, u.email,
+			(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL AND c.status = 'approved') AS comments_count,
+			b.collection_id, b.created_at
+		FROM bookmarks b
+		JOIN posts p ON p.id = b.post_id
+		LEFT JOIN users u ON u.id = p.user_id
+		WHERE b.user_id = $1 AND p.deleted_at IS NULL AND ` + postVisibleTo("$1")
+
+	args := []any{userID}
+
+	if collectionID != nil {
+		args = append(args, *collectionID)
+		query += fmt.Sprintf(` AND b.collection_id = $%d`, len(args))
+	}
+
+	if fq.Search != "" {
+		args = append(args, fq.Search)
+		query += fmt.Sprintf(` AND (p.title ILIKE '%%' || $%[1]d || '%%' OR p.content ILIKE '%%' || $%[1]d || '%%')`, len(args))
+	}
+
+	if len(fq.Tags) > 0 {
+		args = append(args, pq.Array(fq.Tags))
+		query += fmt.Sprintf(` AND (p.tags @> $%d::text[])`, len(args))
+	}
+
+	dir := fq.direction()
+
+	args = append(args, fq.Limit, fq.Offset)
+	query += fmt.Sprintf(`
+		ORDER BY b.created_at `+dir+`, p.id `+dir+`
+		LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	posts := []BookmarkedPost{}
+
+	for rows.Next() {
+		post := BookmarkedPost{}
+
+		err := rows.Scan(
+			&post.ID,
+			&post.UserId,
+			&post.Title,
+			&post.Content,
+			&post.ContentFormat,
+			&post.CreatedAt,
+			&post.Version,
+			pq.Array(&post.Tags),
+			&post.User.Username,
+			&post.User.Email,
+			&post.CommentsCount,
+			&post.CollectionID,
+			&post.BookmarkedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+func (s *BookmarksStore) CreateCollection(ctx context.Context, collection *BookmarkCollection) error {
+	query := `INSERT INTO bookmark_collections (user_id, name) VALUES ($1, $2) RETURNING id, created_at`
+
+	err := s.db.QueryRowContext(ctx, query, collection.UserID, collection.Name).Scan(
+		&collection.ID,
+		&collection.CreatedAt,
+	)
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrDuplicateCollection
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetCollection returns the collection only if it belongs to userID.
+func (s *BookmarksStore) GetCollection(ctx context.Context, id, userID int64) (*BookmarkCollection, error) {
+	query := `
+		SELECT bc.id, bc.user_id, bc.name, bc.created_at, COUNT(b.post_id)
+		FROM bookmark_collections bc
+		LEFT JOIN bookmarks b ON b.collection_id = bc.id
+		WHERE bc.id = $1 AND bc.user_id = $2
+		GROUP BY bc.id`
+
+	collection := &BookmarkCollection{}
+
+	err := s.db.QueryRowContext(ctx, query, id, userID).Scan(
+		&collection.ID,
+		&collection.UserID,
+		&collection.Name,
+		&collection.CreatedAt,
+		&collection.BookmarksCount,
+	)
+
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return collection, nil
+}
+
+func (s *BookmarksStore) GetCollections(ctx context.Context, userID int64) ([]BookmarkCollection, error) {
+	query := `
+		SELECT bc.id, bc.user_id, bc.name, bc.created_at, COUNT(b.post_id)
+		FROM bookmark_collections bc
+		LEFT JOIN bookmarks b ON b.collection_id = bc.id
+		WHERE bc.user_id = $1
+		GROUP BY bc.id
+		ORDER BY bc.name`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	collections := []BookmarkCollection{}
+
+	for rows.Next() {
+		collection := BookmarkCollection{}
+
+		err := rows.Scan(
+			&collection.ID,
+			&collection.UserID,
+			&collection.Name,
+			&collection.CreatedAt,
+			&collection.BookmarksCount,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		collections = append(collections, collection)
+	}
+
+	return collections, rows.Err()
+}
+
+func (s *BookmarksStore) RenameCollection(ctx context.Context, collection *BookmarkCollection) error {
+	query := `UPDATE bookmark_collections SET name = $1 WHERE id = $2 AND user_id = $3`
+
+	res, err := s.db.ExecContext(ctx, query, collection.Name, collection.ID, collection.UserID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrDuplicateCollection
+		}
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteCollection removes the collection; its bookmarks are kept and fall
+// back to being uncategorized.
+func (s *BookmarksStore) DeleteCollection(ctx context.Context, id, userID int64) error {
+	query := `DELETE FROM bookmark_collections WHERE id = $1 AND user_id = $2`
+
+	res, err := s.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
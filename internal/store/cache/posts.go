@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type PostStore struct {
+	rdb *redis.Client
+}
+
+var PostHTMLExpiry = time.Hour * 24
+
+// Rendered HTML is keyed by version so an update never serves stale markup;
+// old versions simply expire.
+func (s *PostStore) GetContentHTML(ctx context.Context, postID int64, version int) (string, error) {
+	cacheKey := fmt.Sprintf("post:%d:v%d:html", postID, version)
+
+	return s.rdb.Get(ctx, cacheKey).Result()
+}
+
+func (s *PostStore) SetContentHTML(ctx context.Context, postID int64, version int, html string) error {
+	cacheKey := fmt.Sprintf("post:%d:v%d:html", postID, version)
+
+	return s.rdb.SetEX(ctx, cacheKey, html, PostHTMLExpiry).Err()
+}
@@ -11,12 +11,27 @@ type Storage struct {
 	Users interface {
 		Get(context.Context, int64) (*store.User, error)
 		Set(context.Context, *store.User) error
+		Delete(context.Context, int64) error
+	}
+
+	Posts interface {
+		GetContentHTML(context.Context, int64, int) (string, error)
+		SetContentHTML(context.Context, int64, int, string) error
+	}
+
+	Trending interface {
+		GetTags(context.Context, string) ([]store.TrendingTag, error)
+		SetTags(context.Context, string, []store.TrendingTag) error
+		GetPosts(context.Context, string) ([]store.TrendingPost, error)
+		SetPosts(context.Context, string, []store.TrendingPost) error
 	}
 }
 
 func NewRedisStore(rdb *redis.Client) Storage {
 	return Storage{
 		Users: &UserStore{rdb: rdb},
+		Posts: &PostStore{rdb: rdb},
+		Trending: &TrendingStore{rdb: rdb},
 	}
 
 }
\ No newline at end of file
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"social/internal/store"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type TrendingStore struct {
+	rdb *redis.Client
+}
+
+// Snapshots outlive a few job runs so a slow or failed run doesn't leave the
+// explore page empty.
+var TrendingExpiry = time.Hour * 2
+
+func (s *TrendingStore) GetTags(ctx context.Context, window string) ([]store.TrendingTag, error) {
+	tags := []store.TrendingTag{}
+
+	err := s.get(ctx, fmt.Sprintf("trending:tags:%s", window), &tags)
+
+	return tags, err
+}
+
+func (s *TrendingStore) SetTags(ctx context.Context, window string, tags []store.TrendingTag) error {
+	return s.set(ctx, fmt.Sprintf("trending:tags:%s", window), tags)
+}
+
+func (s *TrendingStore) GetPosts(ctx context.Context, window string) ([]store.TrendingPost, error) {
+	posts := []store.TrendingPost{}
+
+	err := s.get(ctx, fmt.Sprintf("trending:posts:%s", window), &posts)
+
+	return posts, err
+}
+
+func (s *TrendingStore) SetPosts(ctx context.Context, window string, posts []store.TrendingPost) error {
+	return s.set(ctx, fmt.Sprintf("trending:posts:%s", window), posts)
+}
+
+func (s *TrendingStore) get(ctx context.Context, cacheKey string, v any) error {
+	data, err := s.rdb.Get(ctx, cacheKey).Result()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(data), v)
+}
+
+func (s *TrendingStore) set(ctx context.Context, cacheKey string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.rdb.SetEX(ctx, cacheKey, data, TrendingExpiry).Err()
+}
@@ -44,4 +44,10 @@ func (s *UserStore) Set(ctx context.Context, user *store.User) error {
 	}
 
 	return s.rdb.SetEX(ctx, cacheKey, data, UserExpiry).Err()
-}
\ No newline at end of file
+}
+
+func (s *UserStore) Delete(ctx context.Context, id int64) error {
+	cacheKey := fmt.Sprintf("user:%d", id)
+
+	return s.rdb.Del(ctx, cacheKey).Err()
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"fmt"
 )
 
 type Comment struct {
@@ -12,40 +13,187 @@ type Comment struct {
 	Content   string `json:"content"`
 	CreatedAt string `json:"created_at"`
 	User      User `json:"user"`	
+	// Mentions are stored along with the comment, like a post's, except
+	// while it's held for approval: they're only resolved once approved.
+	Mentions  []Mention `json:"mentions"`
+	DeletedAt *string `json:"deleted_at,omitempty"`
+	EditedAt  *string `json:"edited_at"`
+	// Hidden is set when the post's author hid the comment. Hidden comments
+	// are only listed for the post's author and the comment's author.
+	Hidden bool `json:"hidden"`
+	ParentID *int64 `json:"parent_id"`
+	// Depth is how far below a top-level comment this one sits.
+	Depth        int `json:"depth"`
+	RepliesCount int `json:"replies_count"`
+	// Replies holds the replies loaded with the comment. When there are
+	// more than were loaded, RepliesCursor continues after the last one;
+	// when none were loaded, the replies endpoint starts from the first.
+	Replies       []Comment `json:"replies,omitempty"`
+	RepliesCursor string    `json:"replies_cursor,omitempty"`
+	// Deleted marks a deleted comment kept as a placeholder because it
+	// still has replies. Its content and author are blanked.
+	Deleted bool `json:"deleted"`
+	// Status is pending while the comment awaits the post author's
+	// approval. Pending comments are only listed for the post's author and
+	// the comment's author.
+	Status string `json:"status"`
+}
+
+const (
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+)
+
+// Comment sort orders for the first level of a comment tree. Replies below
+// it are always listed oldest first.
+const (
+	CommentsSortNewest = "newest"
+	CommentsSortOldest = "oldest"
+	// CommentsSortTop ranks comments by their number of replies.
+	CommentsSortTop = "top"
+)
+
+// CommentTreeQuery selects a page of comments along with their replies.
+type CommentTreeQuery struct {
+	// ParentID lists the replies to a comment instead of the post's
+	// top-level comments.
+	ParentID *int64
+	// Sort orders the first level; it defaults to newest.
+	Sort string
+	// Cursor continues after a comment from a previous page, as returned
+	// by CommentCursor for the same sort.
+	Cursor *Cursor
+	// Limit caps the comments on the first level; zero means no cap.
+	Limit int
+	// Depth is how many levels to load, counting the first one.
+	Depth int
+	// RepliesLimit caps the replies loaded under each comment.
+	RepliesLimit int
 }
 
 type CommentsStore struct {
 	db *sql.DB
 }
 
-func (s * CommentsStore) GetByPostID(ctx context.Context, postID int) ([]Comment, error) {
-	// Get comments by post id
-	query := `SELECT c.id, c.post_id, c.user_id, c.content, c.created_at, users.id, users.username FROM comments c
-		JOIN users ON users.id = c.user_id
-		WHERE c.post_id = $1
-		ORDER BY c.created_at DESC`
+// GetByPostID loads the comment tree of a post as seen by viewerID. The
+// first level follows q.Sort and the replies below it come oldest first.
+func (s *CommentsStore) GetByPostID(ctx context.Context, postID int, viewerID int64, q CommentTreeQuery) ([]Comment, error) {
+	// A comment is visible unless it is hidden from the viewer, deleted
+	// without any live reply to keep it around for, or written by someone
+	// the viewer muted or shares a block with.
+	const visible = `
+		(c.hidden_at IS NULL OR c.user_id = $2 OR p.user_id = $2)
+		AND (c.status = 'approved' OR c.user_id = $2 OR p.user_id = $2)
+		AND (c.deleted_at IS NULL
+			OR EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = c.id AND r.deleted_at IS NULL))
+		AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.muter_id = $2 AND m.muted_id = c.user_id)
+		AND NOT EXISTS (SELECT 1 FROM blocks b
+			WHERE (b.blocker_id = $2 AND b.blocked_id = c.user_id) OR (b.blocker_id = c.user_id AND b.blocked_id = $2))`
+
+	args := []any{postID, viewerID}
+
+	first := `c.parent_id IS NULL`
+
+	if q.ParentID != nil {
+		args = append(args, *q.ParentID)
+		first = fmt.Sprintf(`c.parent_id = $%d`, len(args))
+	}
+
+	order := `c.created_at DESC, c.id DESC`
+
+	switch q.Sort {
+	case CommentsSortOldest:
+		order = `c.created_at ASC, c.id ASC`
+		if q.Cursor != nil {
+			args = append(args, q.Cursor.CreatedAt, q.Cursor.ID)
+			first += fmt.Sprintf(` AND (c.created_at, c.id) > ($%d, $%d)`, len(args)-1, len(args))
+		}
+	case CommentsSortTop:
+		order = `rc.n DESC, c.created_at DESC, c.id DESC`
+		if q.Cursor != nil {
+			args = append(args, q.Cursor.Rank, q.Cursor.CreatedAt, q.Cursor.ID)
+			first += fmt.Sprintf(` AND (rc.n, c.created_at, c.id) < ($%d, $%d, $%d)`, len(args)-2, len(args)-1, len(args))
+		}
+	default:
+		if q.Cursor != nil {
+			args = append(args, q.Cursor.CreatedAt, q.Cursor.ID)
+			first += fmt.Sprintf(` AND (c.created_at, c.id) < ($%d, $%d)`, len(args)-1, len(args))
+		}
+	}
+
+	limit := ``
+
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		limit = fmt.Sprintf(`LIMIT $%d`, len(args))
+	}
+
+	args = append(args, q.Depth, q.RepliesLimit)
 
-	rows, err := s.db.QueryContext(ctx, query, postID)
+	query := fmt.Sprintf(`
+		WITH RECURSIVE tree AS (
+			SELECT f.id, 1 AS level, f.pos
+			FROM (
+				SELECT c.id, row_number() OVER (ORDER BY %[1]s) AS pos
+				FROM comments c
+				JOIN posts p ON p.id = c.post_id
+				CROSS JOIN LATERAL (
+					SELECT COUNT(*) AS n FROM comments r WHERE r.parent_id = c.id AND r.deleted_at IS NULL AND r.status = 'approved'
+				) rc
+				WHERE c.post_id = $1 AND %[2]s AND %[3]s
+				ORDER BY %[1]s
+				%[4]s
+			) f
+			UNION ALL
+			SELECT ch.id, t.level + 1, ch.pos
+			FROM tree t
+			JOIN LATERAL (
+				SELECT c.id, row_number() OVER (ORDER BY c.created_at, c.id) AS pos
+				FROM comments c
+				JOIN posts p ON p.id = c.post_id
+				WHERE c.parent_id = t.id AND %[3]s
+				ORDER BY c.created_at, c.id
+				LIMIT $%[6]d
+			) ch ON true
+			WHERE t.level < $%[5]d
+		)
+		SELECT c.id, c.post_id, c.user_id, c.parent_id, c.depth, c.content, c.created_at, c.edited_at,
+			c.hidden_at IS NOT NULL, c.deleted_at IS NOT NULL, c.status,
+			(SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id AND r.deleted_at IS NULL AND r.status = 'approved') AS replies_count,
+			u.id, u.username
+		FROM tree t
+		JOIN comments c ON c.id = t.id
+		JOIN users u ON u.id = c.user_id
+		ORDER BY t.level, t.pos`, order, first, visible, limit, len(args)-1, len(args))
 
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 
 	defer rows.Close()
 
-	comments := []Comment{}
+	// Rows come level by level, so every parent is read before its replies.
+	var roots []*Comment
+	byID := map[int]*Comment{}
+	children := map[int][]*Comment{}
 
 	for rows.Next() {
-		comment := Comment{}
-
-		comment.User = User{}
+		comment := &Comment{}
 
 		err := rows.Scan(
 			&comment.ID,
 			&comment.PostID,
 			&comment.UserID,
+			&comment.ParentID,
+			&comment.Depth,
 			&comment.Content,
 			&comment.CreatedAt,
+			&comment.EditedAt,
+			&comment.Hidden,
+			&comment.Deleted,
+			&comment.Status,
+			&comment.RepliesCount,
 			&comment.User.ID,
 			&comment.User.Username,
 		)
@@ -54,30 +202,294 @@ func (s * CommentsStore) GetByPostID(ctx context.Context, postID int) ([]Comment
 			return nil, err
 		}
 
-		comments = append(comments, comment)
+		if comment.Deleted {
+			comment.Content = ""
+			comment.UserID = 0
+			comment.User = User{}
+		}
+
+		byID[comment.ID] = comment
+
+		// The first level's parent is never part of the result.
+		if comment.ParentID != nil && byID[int(*comment.ParentID)] != nil {
+			children[int(*comment.ParentID)] = append(children[int(*comment.ParentID)], comment)
+			continue
+		}
+
+		roots = append(roots, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	comments := make([]Comment, 0, len(roots))
+
+	for _, root := range roots {
+		c, err := assembleComment(root, children)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, c)
 	}
 
 	return comments, nil
 }
 
+// CommentCursor returns the cursor continuing after comment in a list
+// sorted by sort.
+func CommentCursor(comment Comment, sort string) (Cursor, error) {
+	cursor, err := NewCursor(comment.CreatedAt, int64(comment.ID))
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	if sort == CommentsSortTop {
+		cursor.Rank = int64(comment.RepliesCount)
+	}
+
+	return cursor, nil
+}
+
+// assembleComment attaches the loaded replies below comment, recursively,
+// and sets the cursor to load the rest.
+func assembleComment(comment *Comment, children map[int][]*Comment) (Comment, error) {
+	for _, child := range children[comment.ID] {
+		reply, err := assembleComment(child, children)
+		if err != nil {
+			return Comment{}, err
+		}
+
+		comment.Replies = append(comment.Replies, reply)
+	}
+
+	if n := len(comment.Replies); n > 0 && comment.RepliesCount > n {
+		last := comment.Replies[n-1]
+
+		cursor, err := NewCursor(last.CreatedAt, int64(last.ID))
+		if err != nil {
+			return Comment{}, err
+		}
+
+		comment.RepliesCursor = cursor.Encode()
+	}
+
+	return *comment, nil
+}
+
 func (s *CommentsStore) Create(ctx context.Context, comment *Comment) error {
 	// Create a new comment
-	query := `INSERT INTO comments (post_id, user_id, content) VALUES ($1, $2, $3) RETURNING id, created_at`
-
-	err := s.db.QueryRowContext(
-		ctx,
-		query,
-		comment.PostID,
-		comment.UserID,
-		comment.Content,
-	).Scan(
+	query := `
+		INSERT INTO comments (post_id, user_id, content, parent_id, depth, status)
+		VALUES ($1, $2, $3, $4, COALESCE((SELECT depth + 1 FROM comments WHERE id = $4), 0), $5)
+		RETURNING id, created_at, depth`
+
+	if comment.Status == "" {
+		comment.Status = CommentStatusApproved
+	}
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			comment.PostID,
+			comment.UserID,
+			comment.Content,
+			comment.ParentID,
+			comment.Status,
+		).Scan(
+			&comment.ID,
+			&comment.CreatedAt,
+			&comment.Depth,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		return storeCommentMentions(ctx, tx, comment)
+	})
+}
+
+// storeCommentMentions replaces the mentions stored for comment with the
+// candidates it holds, unless it's held for approval.
+func storeCommentMentions(ctx context.Context, tx *sql.Tx, comment *Comment) error {
+	if comment.Status == CommentStatusPending {
+		comment.Mentions = []Mention{}
+		return nil
+	}
+
+	commentID := int64(comment.ID)
+
+	mentions, err := replaceMentions(ctx, tx, int64(comment.PostID), &commentID, int64(comment.UserID), comment.Mentions)
+	if err != nil {
+		return err
+	}
+
+	comment.Mentions = mentions
+
+	return nil
+}
+
+// GetByID returns a live comment with its author.
+func (s *CommentsStore) GetByID(ctx context.Context, id int) (*Comment, error) {
+	query := `
+		SELECT c.id, c.post_id, c.user_id, c.parent_id, c.depth, c.content, c.created_at, c.edited_at,
+			c.hidden_at IS NOT NULL, c.status,
+			(SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id AND r.deleted_at IS NULL AND r.status = 'approved'),
+			u.id, u.username
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1 AND c.deleted_at IS NULL`
+
+	comment := &Comment{}
+
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
 		&comment.ID,
+		&comment.PostID,
+		&comment.UserID,
+		&comment.ParentID,
+		&comment.Depth,
+		&comment.Content,
 		&comment.CreatedAt,
+		&comment.EditedAt,
+		&comment.Hidden,
+		&comment.Status,
+		&comment.RepliesCount,
+		&comment.User.ID,
+		&comment.User.Username,
 	)
 
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return comment, nil
+}
+
+// Update replaces the comment's content and mentions and stamps it as
+// edited.
+func (s *CommentsStore) Update(ctx context.Context, comment *Comment) error {
+	query := `
+		UPDATE comments
+		SET content = $1, edited_at = now()
+		WHERE id = $2 AND deleted_at IS NULL
+		RETURNING edited_at, status`
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, comment.Content, comment.ID).Scan(&comment.EditedAt, &comment.Status)
+
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		return storeCommentMentions(ctx, tx, comment)
+	})
+}
+
+// SetHidden hides or unhides a comment on behalf of the post's author.
+func (s *CommentsStore) SetHidden(ctx context.Context, id int, hidden bool) error {
+	query := `
+		UPDATE comments
+		SET hidden_at = CASE WHEN $2 THEN COALESCE(hidden_at, now()) END
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, id, hidden)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
 
+	if rows == 0 {
+		return ErrNotFound
+	}
+
 	return nil
-}
\ No newline at end of file
+}
+
+// GetPending lists the comments on a post waiting for approval, oldest
+// first.
+func (s *CommentsStore) GetPending(ctx context.Context, postID int, fq PaginatedFieldQuery) ([]Comment, error) {
+	query := `
+		SELECT c.id, c.post_id, c.user_id, c.parent_id, c.depth, c.content, c.created_at, c.status,
+			u.id, u.username
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.post_id = $1 AND c.status = 'pending' AND c.deleted_at IS NULL
+		ORDER BY c.created_at ASC, c.id ASC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := s.db.QueryContext(ctx, query, postID, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	comments := []Comment{}
+
+	for rows.Next() {
+		comment := Comment{}
+
+		err := rows.Scan(
+			&comment.ID,
+			&comment.PostID,
+			&comment.UserID,
+			&comment.ParentID,
+			&comment.Depth,
+			&comment.Content,
+			&comment.CreatedAt,
+			&comment.Status,
+			&comment.User.ID,
+			&comment.User.Username,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// Approve publishes a pending comment and stores the mentions it holds.
+func (s *CommentsStore) Approve(ctx context.Context, comment *Comment) error {
+	query := `UPDATE comments SET status = 'approved' WHERE id = $1 AND status = 'pending' AND deleted_at IS NULL`
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, query, comment.ID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		comment.Status = CommentStatusApproved
+
+		return storeCommentMentions(ctx, tx, comment)
+	})
+}
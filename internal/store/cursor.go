@@ -0,0 +1,84 @@
+package store
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor marks a position in a list ordered by (created_at, id), optionally
+// preceded by a ranking value such as a count. Clients only ever see its
+// opaque encoded form.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+	Rank      int64
+	// Before asks for the page preceding the position rather than the one
+	// following it.
+	Before bool
+}
+
+// cursorBeforePrefix marks an encoded cursor as pointing backwards.
+const cursorBeforePrefix = "<"
+
+// NewCursor builds a cursor from a row's created_at, as scanned into a
+// string, and id.
+func NewCursor(createdAt string, id int64) (Cursor, error) {
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	return Cursor{CreatedAt: t, ID: id}, nil
+}
+
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%s|%d", c.CreatedAt.UTC().Format(time.RFC3339Nano), c.ID)
+
+	if c.Rank != 0 {
+		raw += fmt.Sprintf("|%d", c.Rank)
+	}
+
+	if c.Before {
+		raw = cursorBeforePrefix + raw
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	before := strings.HasPrefix(string(raw), cursorBeforePrefix)
+
+	parts := strings.Split(strings.TrimPrefix(string(raw), cursorBeforePrefix), "|")
+	if len(parts) != 2 && len(parts) != 3 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	n, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	cursor := Cursor{CreatedAt: t, ID: n, Before: before}
+
+	if len(parts) == 3 {
+		cursor.Rank, err = strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return Cursor{}, ErrInvalidCursor
+		}
+	}
+
+	return cursor, nil
+}
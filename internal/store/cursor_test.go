@@ -0,0 +1,44 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+
+	tests := map[string]Cursor{
+		"after":          {CreatedAt: createdAt, ID: 42},
+		"before":         {CreatedAt: createdAt, ID: 42, Before: true},
+		"ranked":         {CreatedAt: createdAt, ID: 7, Rank: 15},
+		"ranked, before": {CreatedAt: createdAt, ID: 7, Rank: 15, Before: true},
+	}
+
+	for name, want := range tests {
+		got, err := DecodeCursor(want.Encode())
+		if err != nil {
+			t.Errorf("%s: decoding: %v", name, err)
+			continue
+		}
+
+		if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID || got.Rank != want.Rank || got.Before != want.Before {
+			t.Errorf("%s: got %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsMalformedInput(t *testing.T) {
+	tests := map[string]string{
+		"not base64":    "%%%",
+		"missing id":    "MjAyNC0wNS0wMVQxMjozMDowMFo",
+		"bad timestamp": "eWVzdGVyZGF5fDQy",
+	}
+
+	for name, s := range tests {
+		if _, err := DecodeCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: got %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
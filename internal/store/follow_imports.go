@@ -0,0 +1,418 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	FollowImportPending   = "pending"
+	FollowImportRunning   = "running"
+	FollowImportCompleted = "completed"
+)
+
+// Outcomes of a single import row.
+const (
+	FollowImportRowPending          = "pending"
+	FollowImportRowFollowed         = "followed"
+	FollowImportRowRequested        = "requested"
+	FollowImportRowAlreadyFollowing = "already_following"
+	FollowImportRowNotFound         = "not_found"
+	FollowImportRowBlocked          = "blocked"
+	FollowImportRowSelf             = "self"
+)
+
+// followImportStaleAfter is how long a running import can go without
+// progress before another worker may pick it up again.
+const followImportStaleAfter = 10 * time.Minute
+
+// FollowImport is an asynchronous request to follow a list of users,
+// identified by username or email.
+type FollowImport struct {
+	ID          int64             `json:"id"`
+	UserID      int64             `json:"user_id"`
+	Status      string            `json:"status"`
+	Total       int               `json:"total"`
+	Processed   int               `json:"processed"`
+	NotBefore   *string           `json:"not_before,omitempty"`
+	CreatedAt   string            `json:"created_at"`
+	CompletedAt *string           `json:"completed_at,omitempty"`
+	Rows        []FollowImportRow `json:"rows,omitempty"`
+}
+
+type FollowImportRow struct {
+	Line       int    `json:"line"`
+	Identifier string `json:"identifier"`
+	Status     string `json:"status"`
+	UserID     *int64 `json:"user_id,omitempty"`
+}
+
+// FollowRateLimit caps how many follows and follow requests a user can
+// make within a window.
+type FollowRateLimit struct {
+	Max    int
+	Window time.Duration
+}
+
+type FollowImportsStore struct {
+	db *sql.DB
+}
+
+// Create stores a pending import of rows, keeping the line each identifier
+// came from.
+func (s *FollowImportsStore) Create(ctx context.Context, imp *FollowImport, rows []FollowImportRow) error {
+	lines := make([]int64, len(rows))
+	identifiers := make([]string, len(rows))
+
+	for i, row := range rows {
+		lines[i] = int64(row.Line)
+		identifiers[i] = row.Identifier
+	}
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO follow_imports (user_id, total)
+			VALUES ($1, $2)
+			RETURNING id, status, created_at`
+
+		err := tx.QueryRowContext(ctx, query, imp.UserID, len(identifiers)).Scan(
+			&imp.ID,
+			&imp.Status,
+			&imp.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		imp.Total = len(identifiers)
+
+		query = `
+			INSERT INTO follow_import_rows (import_id, line, identifier)
+			SELECT $1, t.line, t.identifier
+			FROM unnest($2::int[], $3::text[]) AS t(line, identifier)`
+
+		_, err = tx.ExecContext(ctx, query, imp.ID, pq.Array(lines), pq.Array(identifiers))
+
+		return err
+	})
+}
+
+// GetByID returns one of userID's imports with the result of every row.
+func (s *FollowImportsStore) GetByID(ctx context.Context, id, userID int64) (*FollowImport, error) {
+	query := `
+		SELECT id, user_id, status, total, processed, not_before, created_at, completed_at
+		FROM follow_imports
+		WHERE id = $1 AND user_id = $2`
+
+	imp := &FollowImport{}
+
+	err := s.db.QueryRowContext(ctx, query, id, userID).Scan(
+		&imp.ID,
+		&imp.UserID,
+		&imp.Status,
+		&imp.Total,
+		&imp.Processed,
+		&imp.NotBefore,
+		&imp.CreatedAt,
+		&imp.CompletedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	query = `
+		SELECT line, identifier, status, user_id
+		FROM follow_import_rows
+		WHERE import_id = $1
+		ORDER BY line`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	imp.Rows = []FollowImportRow{}
+
+	for rows.Next() {
+		var row FollowImportRow
+
+		if err := rows.Scan(&row.Line, &row.Identifier, &row.Status, &row.UserID); err != nil {
+			return nil, err
+		}
+
+		imp.Rows = append(imp.Rows, row)
+	}
+
+	return imp, rows.Err()
+}
+
+// Claim marks the oldest import that is ready to run as running and returns
+// it, or ErrNotFound when there is none. Imports left running by a worker
+// that stopped making progress are claimed again.
+func (s *FollowImportsStore) Claim(ctx context.Context) (*FollowImport, error) {
+	query := `
+		UPDATE follow_imports SET status = 'running', claimed_at = now()
+		WHERE id = (
+			SELECT id FROM follow_imports
+			WHERE (status = 'pending' AND (not_before IS NULL OR not_before <= now()))
+				OR (status = 'running' AND claimed_at < now() - $1 * interval '1 second')
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, status, total, processed, created_at`
+
+	imp := &FollowImport{}
+
+	err := s.db.QueryRowContext(ctx, query, followImportStaleAfter.Seconds()).Scan(
+		&imp.ID,
+		&imp.UserID,
+		&imp.Status,
+		&imp.Total,
+		&imp.Processed,
+		&imp.CreatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return imp, nil
+}
+
+// followTarget is a user an import row resolved to, with their relationship
+// to the importing user.
+type followTarget struct {
+	id        int64
+	private   bool
+	following bool
+	requested bool
+	blocked   bool
+}
+
+// ProcessBatch resolves up to size pending rows of imp and follows the users
+// they name in a single transaction, or sends follow requests to private
+// accounts. It returns the rows it settled, and whether it stopped because
+// imp's user reached limit; the rows it couldn't get to stay pending.
+func (s *FollowImportsStore) ProcessBatch(ctx context.Context, imp *FollowImport, size int, limit FollowRateLimit) ([]FollowImportRow, bool, error) {
+	if size < 1 {
+		return nil, false, ErrInvalidBatchSize
+	}
+
+	settled := []FollowImportRow{}
+	var limited bool
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT line, identifier
+			FROM follow_import_rows
+			WHERE import_id = $1 AND status = 'pending'
+			ORDER BY line
+			LIMIT $2
+			FOR UPDATE`
+
+		rows, err := tx.QueryContext(ctx, query, imp.ID, size)
+		if err != nil {
+			return err
+		}
+
+		batch := []FollowImportRow{}
+		identifiers := []string{}
+
+		for rows.Next() {
+			var row FollowImportRow
+
+			if err := rows.Scan(&row.Line, &row.Identifier); err != nil {
+				rows.Close()
+				return err
+			}
+
+			batch = append(batch, row)
+			identifiers = append(identifiers, row.Identifier)
+		}
+
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		query = `
+			SELECT
+				(SELECT COUNT(*) FROM followers WHERE follower_id = $1 AND created_at >= now() - $2 * interval '1 second') +
+				(SELECT COUNT(*) FROM follow_requests WHERE requester_id = $1 AND created_at >= now() - $2 * interval '1 second')`
+
+		var used int
+
+		if err := tx.QueryRowContext(ctx, query, imp.UserID, limit.Window.Seconds()).Scan(&used); err != nil {
+			return err
+		}
+
+		remaining := limit.Max - used
+
+		targets, err := resolveFollowTargets(ctx, tx, imp.UserID, identifiers)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range batch {
+			target, ok := targets[row.Identifier]
+
+			switch {
+			case !ok:
+				row.Status = FollowImportRowNotFound
+			case target.id == imp.UserID:
+				row.Status = FollowImportRowSelf
+			case target.blocked:
+				row.Status = FollowImportRowBlocked
+			case target.following:
+				row.Status = FollowImportRowAlreadyFollowing
+			case target.requested:
+				row.Status = FollowImportRowRequested
+			case remaining <= 0:
+				limited = true
+			case target.private:
+				query := `
+					INSERT INTO follow_requests (user_id, requester_id) VALUES ($1, $2)
+					ON CONFLICT DO NOTHING`
+
+				if _, err := tx.ExecContext(ctx, query, target.id, imp.UserID); err != nil {
+					return err
+				}
+
+				row.Status = FollowImportRowRequested
+				target.requested = true
+				remaining--
+			default:
+				err := insertFollow(ctx, tx, imp.UserID, target.id)
+				switch {
+				case err == nil:
+					row.Status = FollowImportRowFollowed
+					target.following = true
+					remaining--
+				case errors.Is(err, ErrAlreadyFollowing):
+					row.Status = FollowImportRowAlreadyFollowing
+					target.following = true
+				default:
+					return err
+				}
+			}
+
+			if limited {
+				break
+			}
+
+			if ok {
+				row.UserID = &target.id
+			}
+
+			query := `
+				UPDATE follow_import_rows SET status = $3, user_id = $4
+				WHERE import_id = $1 AND line = $2`
+
+			if _, err := tx.ExecContext(ctx, query, imp.ID, row.Line, row.Status, row.UserID); err != nil {
+				return err
+			}
+
+			settled = append(settled, row)
+		}
+
+		query = `
+			UPDATE follow_imports SET processed = processed + $2, claimed_at = now()
+			WHERE id = $1
+			RETURNING processed`
+
+		return tx.QueryRowContext(ctx, query, imp.ID, len(settled)).Scan(&imp.Processed)
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return settled, limited, nil
+}
+
+// resolveFollowTargets looks up the users named by identifiers, as either
+// usernames or emails, keyed by the identifier that matched them.
+func resolveFollowTargets(ctx context.Context, tx *sql.Tx, userID int64, identifiers []string) (map[string]*followTarget, error) {
+	query := `
+		SELECT u.id, u.username, u.email, u.is_private,
+			EXISTS (SELECT 1 FROM followers f WHERE f.user_id = u.id AND f.follower_id = $1),
+			EXISTS (SELECT 1 FROM follow_requests fr WHERE fr.user_id = u.id AND fr.requester_id = $1),
+			EXISTS (SELECT 1 FROM blocks b
+				WHERE (b.blocker_id = $1 AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = $1))
+		FROM users u
+		WHERE u.username = ANY($2) OR u.email = ANY($2)`
+
+	rows, err := tx.QueryContext(ctx, query, userID, pq.Array(identifiers))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	targets := map[string]*followTarget{}
+
+	for rows.Next() {
+		target := &followTarget{}
+
+		var username, email string
+
+		err := rows.Scan(
+			&target.id,
+			&username,
+			&email,
+			&target.private,
+			&target.following,
+			&target.requested,
+			&target.blocked,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		targets[username] = target
+		targets[email] = target
+	}
+
+	return targets, rows.Err()
+}
+
+// Defer puts a running import back in the queue, to be resumed no earlier
+// than until.
+func (s *FollowImportsStore) Defer(ctx context.Context, id int64, until time.Time) error {
+	query := `UPDATE follow_imports SET status = 'pending', not_before = $2 WHERE id = $1`
+
+	_, err := s.db.ExecContext(ctx, query, id, until)
+
+	return err
+}
+
+func (s *FollowImportsStore) Complete(ctx context.Context, id int64) error {
+	query := `
+		UPDATE follow_imports SET status = 'completed', not_before = NULL, completed_at = now()
+		WHERE id = $1`
+
+	_, err := s.db.ExecContext(ctx, query, id)
+
+	return err
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// FollowRequest is a pending request to follow a private account.
+type FollowRequest struct {
+	UserID      int64  `json:"user_id"`
+	RequesterID int64  `json:"requester_id"`
+	CreatedAt   string `json:"created_at"`
+	Requester   User   `json:"requester"`
+}
+
+type FollowRequestsStore struct {
+	db *sql.DB
+}
+
+func (s *FollowRequestsStore) Create(ctx context.Context, request *FollowRequest) error {
+	query := `
+		INSERT INTO follow_requests (user_id, requester_id)
+		VALUES ($1, $2)
+		RETURNING created_at`
+
+	err := s.db.QueryRowContext(ctx, query, request.UserID, request.RequesterID).Scan(&request.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrFollowRequestExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetIncoming returns the requests waiting on userID's approval, newest
+// first. Cursors are keyed on the request's created_at and requester id.
+func (s *FollowRequestsStore) GetIncoming(ctx context.Context, userID int64, cursor *Cursor, limit int) ([]FollowRequest, error) {
+	query := `
+		SELECT fr.user_id, fr.requester_id, fr.created_at, u.id, u.username
+		FROM follow_requests fr
+		JOIN users u ON u.id = fr.requester_id
+		WHERE fr.user_id = $1`
+
+	args := []any{userID, limit}
+
+	if cursor != nil {
+		query += ` AND (fr.created_at, fr.requester_id) < ($3, $4)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+
+	query += `
+		ORDER BY fr.created_at DESC, fr.requester_id DESC
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	requests := []FollowRequest{}
+
+	for rows.Next() {
+		var request FollowRequest
+
+		err := rows.Scan(
+			&request.UserID,
+			&request.RequesterID,
+			&request.CreatedAt,
+			&request.Requester.ID,
+			&request.Requester.Username,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// Approve turns requesterID's pending request into a follow of userID and
+// reports whether a follow was created; there is none when the requester
+// already followed userID.
+func (s *FollowRequestsStore) Approve(ctx context.Context, userID, requesterID int64) (bool, error) {
+	var created bool
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := deleteFollowRequest(ctx, tx, userID, requesterID); err != nil {
+			return err
+		}
+
+		err := insertFollow(ctx, tx, requesterID, userID)
+		if errors.Is(err, ErrAlreadyFollowing) {
+			return nil
+		}
+
+		created = err == nil
+
+		return err
+	})
+
+	return created, err
+}
+
+// Delete removes a pending request. It serves both the account rejecting
+// it and the requester cancelling it.
+func (s *FollowRequestsStore) Delete(ctx context.Context, userID, requesterID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return deleteFollowRequest(ctx, tx, userID, requesterID)
+	})
+}
+
+func deleteFollowRequest(ctx context.Context, tx *sql.Tx, userID, requesterID int64) error {
+	query := `DELETE FROM follow_requests WHERE user_id = $1 AND requester_id = $2`
+
+	res, err := tx.ExecContext(ctx, query, userID, requesterID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
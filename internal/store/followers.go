@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/lib/pq"
 )
@@ -17,38 +18,203 @@ type FollowersStore struct {
 	db *sql.DB
 }
 
+// Follow makes followerId follow userID. It returns ErrSelfFollow when
+// they are the same user and ErrUserNotFound when userID doesn't exist.
 func (s *FollowersStore) Follow(ctx context.Context, followerId, userID int64) error {
-	// Follow a user
-	query := `INSERT INTO followers (user_id, follower_id) VALUES ($1, $2)`
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return insertFollow(ctx, tx, followerId, userID)
+	})
+}
+
+// insertFollow is the one way a follow gets created, whether directly, by
+// approving a request or by an import. It returns ErrSelfFollow,
+// ErrUserNotFound or ErrAlreadyFollowing without aborting tx, so callers
+// can go on with other follows.
+func insertFollow(ctx context.Context, tx *sql.Tx, followerID, userID int64) error {
+	if followerID == userID {
+		return ErrSelfFollow
+	}
+
+	query := `
+		WITH target AS (
+			SELECT id FROM users WHERE id = $1
+		), inserted AS (
+			INSERT INTO followers (user_id, follower_id)
+			SELECT id, $2 FROM target
+			ON CONFLICT DO NOTHING
+			RETURNING 1
+		)
+		SELECT EXISTS (SELECT 1 FROM target), EXISTS (SELECT 1 FROM inserted)`
 
-	_, err := s.db.ExecContext(ctx, query, userID, followerId)
+	var found, inserted bool
 
+	err := tx.QueryRowContext(ctx, query, userID, followerID).Scan(&found, &inserted)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
-			return ErrAlreadyFollowing
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return ErrUserNotFound
 		}
+		return err
+	}
+
+	switch {
+	case !found:
+		return ErrUserNotFound
+	case !inserted:
+		return ErrAlreadyFollowing
 	}
 
 	return nil
 }
 
+// Unfollow removes followerId's follow of userId, returning ErrNotFollowing
+// when there was none.
 func (s *FollowersStore) Unfollow(ctx context.Context, followerId, userId int64) error {
-	// Unfollow a user
 	query := `
 		DELETE FROM followers 
 		WHERE user_id = $1 
 		AND follower_id = $2`
 
-	_, err := s.db.ExecContext(ctx, query, userId, followerId)
+	res, err := s.db.ExecContext(ctx, query, userId, followerId)
+	if err != nil {
+		return err
+	}
 
+	rows, err := res.RowsAffected()
 	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return ErrNotFollowing
-		default:
-			return err
-		}
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFollowing
 	}
 
 	return nil
 }
+
+// IsFollowing reports whether followerID follows userID.
+func (s *FollowersStore) IsFollowing(ctx context.Context, followerID, userID int64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM followers WHERE user_id = $1 AND follower_id = $2)`
+
+	var following bool
+
+	err := s.db.QueryRowContext(ctx, query, userID, followerID).Scan(&following)
+
+	return following, err
+}
+
+// FollowListEntry is a user in a followers or following list, with flags
+// describing their relationship to the viewer.
+type FollowListEntry struct {
+	User        User   `json:"user"`
+	FollowedAt  string `json:"followed_at"`
+	IsFollowing bool   `json:"is_following"`
+	FollowsYou  bool   `json:"follows_you"`
+}
+
+// GetFollowers returns the users following userID, most recent first.
+func (s *FollowersStore) GetFollowers(ctx context.Context, userID, viewerID int64, cursor *Cursor, limit int) ([]FollowListEntry, error) {
+	return s.list(ctx, "user_id", "follower_id", userID, viewerID, cursor, limit)
+}
+
+// GetFollowing returns the users userID follows, most recent first.
+func (s *FollowersStore) GetFollowing(ctx context.Context, userID, viewerID int64, cursor *Cursor, limit int) ([]FollowListEntry, error) {
+	return s.list(ctx, "follower_id", "user_id", userID, viewerID, cursor, limit)
+}
+
+// list pages through the followers rows where column matches userID,
+// returning the user on the other side of each row. Cursors are keyed on
+// the follow's created_at and that user's id.
+func (s *FollowersStore) list(ctx context.Context, column, other string, userID, viewerID int64, cursor *Cursor, limit int) ([]FollowListEntry, error) {
+	query := fmt.Sprintf(`
+		SELECT u.id, u.username, f.created_at,
+			EXISTS (SELECT 1 FROM followers v WHERE v.user_id = u.id AND v.follower_id = $2) AS is_following,
+			EXISTS (SELECT 1 FROM followers v WHERE v.user_id = $2 AND v.follower_id = u.id) AS follows_you
+		FROM followers f
+		JOIN users u ON u.id = f.%s
+		WHERE f.%s = $1`, other, column)
+
+	args := []any{userID, viewerID, limit}
+
+	if cursor != nil {
+		query += ` AND (f.created_at, u.id) < ($4, $5)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+
+	query += `
+		ORDER BY f.created_at DESC, u.id DESC
+		LIMIT $3`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := []FollowListEntry{}
+
+	for rows.Next() {
+		var entry FollowListEntry
+
+		err := rows.Scan(
+			&entry.User.ID,
+			&entry.User.Username,
+			&entry.FollowedAt,
+			&entry.IsFollowing,
+			&entry.FollowsYou,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// FollowExportEntry is a row of an exported followers or following list.
+type FollowExportEntry struct {
+	Username   string `json:"username"`
+	FollowedAt string `json:"followed_at"`
+}
+
+// ExportFollowers returns every user following userID, oldest first.
+func (s *FollowersStore) ExportFollowers(ctx context.Context, userID int64) ([]FollowExportEntry, error) {
+	return s.export(ctx, "user_id", "follower_id", userID)
+}
+
+// ExportFollowing returns every user userID follows, oldest first.
+func (s *FollowersStore) ExportFollowing(ctx context.Context, userID int64) ([]FollowExportEntry, error) {
+	return s.export(ctx, "follower_id", "user_id", userID)
+}
+
+func (s *FollowersStore) export(ctx context.Context, column, other string, userID int64) ([]FollowExportEntry, error) {
+	query := fmt.Sprintf(`
+		SELECT u.username, f.created_at
+		FROM followers f
+		JOIN users u ON u.id = f.%s
+		WHERE f.%s = $1
+		ORDER BY f.created_at, u.id`, other, column)
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := []FollowExportEntry{}
+
+	for rows.Next() {
+		var entry FollowExportEntry
+
+		if err := rows.Scan(&entry.Username, &entry.FollowedAt); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,239 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type Mention struct {
+	ID        int64  `json:"id"`
+	PostID    int64  `json:"post_id"`
+	CommentID *int64 `json:"comment_id,omitempty"`
+	AuthorID  int64  `json:"author_id"`
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	Offset    int    `json:"offset"`
+	Length    int    `json:"length"`
+	CreatedAt string `json:"created_at"`
+}
+
+type MentionWithContext struct {
+	Mention
+
+	Author  User   `json:"author"`
+	Content string `json:"content"`
+}
+
+type MentionsStore struct {
+	db *sql.DB
+}
+
+// replaceMentions swaps the mentions stored for a post (commentID nil) or
+// one of its comments for the given candidates. Candidates only need
+// Username, Offset and Length; usernames that don't resolve to a user, or
+// resolve to one sharing a block with the author, are dropped.
+func replaceMentions(ctx context.Context, tx *sql.Tx, postID int64, commentID *int64, authorID int64, candidates []Mention) ([]Mention, error) {
+	mentions := []Mention{}
+
+	if err := deleteMentionsBySource(ctx, tx, postID, commentID); err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 {
+		return mentions, nil
+	}
+
+	ids, err := resolveMentionedUsernames(ctx, tx, authorID, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO mentions (post_id, comment_id, author_id, user_id, offset_chars, length_chars)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	for _, m := range candidates {
+		userID, ok := ids[m.Username]
+		if !ok {
+			continue
+		}
+
+		m.PostID = postID
+		m.CommentID = commentID
+		m.AuthorID = authorID
+		m.UserID = userID
+
+		err := tx.QueryRowContext(ctx, query,
+			m.PostID,
+			m.CommentID,
+			m.AuthorID,
+			m.UserID,
+			m.Offset,
+			m.Length,
+		).Scan(&m.ID, &m.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mentions = append(mentions, m)
+	}
+
+	return mentions, nil
+}
+
+// GetByPostID returns the mentions of a live post and of all its live
+// comments.
+func (s *MentionsStore) GetByPostID(ctx context.Context, postID int64) ([]Mention, error) {
+	query := `
+		SELECT m.id, m.post_id, m.comment_id, m.author_id, m.user_id, u.username,
+			m.offset_chars, m.length_chars, m.created_at
+		FROM mentions m
+		JOIN users u ON u.id = m.user_id
+		JOIN posts p ON p.id = m.post_id
+		LEFT JOIN comments c ON c.id = m.comment_id
+		WHERE m.post_id = $1 AND p.deleted_at IS NULL AND (m.comment_id IS NULL OR c.deleted_at IS NULL)
+		ORDER BY m.comment_id NULLS FIRST, m.offset_chars`
+
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	mentions := []Mention{}
+
+	for rows.Next() {
+		m := Mention{}
+
+		err := rows.Scan(
+			&m.ID,
+			&m.PostID,
+			&m.CommentID,
+			&m.AuthorID,
+			&m.UserID,
+			&m.Username,
+			&m.Offset,
+			&m.Length,
+			&m.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mentions = append(mentions, m)
+	}
+
+	return mentions, rows.Err()
+}
+
+// GetByUserID lists the posts and comments that mention userID, newest first
+// unless fq asks otherwise.
+func (s *MentionsStore) GetByUserID(ctx context.Context, userID int64, fq PaginatedFieldQuery) ([]MentionWithContext, error) {
+	dir := fq.direction()
+
+	query := `
+		SELECT m.id, m.post_id, m.comment_id, m.author_id, m.user_id, u.username,
+			m.offset_chars, m.length_chars, m.created_at,
+			a.id, a.username, COALESCE(c.content, p.content)
+		FROM mentions m
+		JOIN users u ON u.id = m.user_id
+		JOIN users a ON a.id = m.author_id
+		JOIN posts p ON p.id = m.post_id
+		LEFT JOIN comments c ON c.id = m.comment_id
+		WHERE m.user_id = $1 AND p.deleted_at IS NULL AND (m.comment_id IS NULL OR c.deleted_at IS NULL)
+			AND ` + postVisibleTo("$1") + `
+		ORDER BY m.created_at ` + dir + `, m.id ` + dir + `
+		LIMIT $2 OFFSET $3`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	mentions := []MentionWithContext{}
+
+	for rows.Next() {
+		m := MentionWithContext{}
+
+		err := rows.Scan(
+			&m.ID,
+			&m.PostID,
+			&m.CommentID,
+			&m.AuthorID,
+			&m.UserID,
+			&m.Username,
+			&m.Offset,
+			&m.Length,
+			&m.CreatedAt,
+			&m.Author.ID,
+			&m.Author.Username,
+			&m.Content,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mentions = append(mentions, m)
+	}
+
+	return mentions, rows.Err()
+}
+
+func deleteMentionsBySource(ctx context.Context, tx *sql.Tx, postID int64, commentID *int64) error {
+	query := `DELETE FROM mentions WHERE post_id = $1 AND comment_id IS NULL`
+	args := []any{postID}
+
+	if commentID != nil {
+		query = `DELETE FROM mentions WHERE post_id = $1 AND comment_id = $2`
+		args = append(args, *commentID)
+	}
+
+	_, err := tx.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+func resolveMentionedUsernames(ctx context.Context, tx *sql.Tx, authorID int64, candidates []Mention) (map[string]int64, error) {
+	usernames := make([]string, 0, len(candidates))
+	for _, m := range candidates {
+		usernames = append(usernames, m.Username)
+	}
+
+	// Users who share a block with the author can't be mentioned by them.
+	query := `
+		SELECT u.id, u.username FROM users u
+		WHERE u.username = ANY($1)
+		AND NOT EXISTS (SELECT 1 FROM blocks b
+			WHERE (b.blocker_id = u.id AND b.blocked_id = $2) OR (b.blocker_id = $2 AND b.blocked_id = u.id))`
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(usernames), authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := map[string]int64{}
+
+	for rows.Next() {
+		var id int64
+		var username string
+
+		if err := rows.Scan(&id, &username); err != nil {
+			return nil, err
+		}
+
+		ids[username] = id
+	}
+
+	return ids, rows.Err()
+}
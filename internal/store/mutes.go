@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// MutesStore tracks muted users. Muting is one-sided and invisible to the
+// muted user: it only filters what the muter sees.
+type MutesStore struct {
+	db *sql.DB
+}
+
+func (s *MutesStore) Mute(ctx context.Context, muterID, mutedID int64) error {
+	query := `INSERT INTO mutes (muter_id, muted_id) VALUES ($1, $2)`
+
+	if _, err := s.db.ExecContext(ctx, query, muterID, mutedID); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrAlreadyMuted
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *MutesStore) Unmute(ctx context.Context, muterID, mutedID int64) error {
+	query := `DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2`
+
+	return execAffectingRow(ctx, s.db, query, muterID, mutedID)
+}
+
+// GetMuted returns the users muterID has muted, most recent first.
+func (s *MutesStore) GetMuted(ctx context.Context, muterID int64, cursor *Cursor, limit int) ([]RelatedUser, error) {
+	query := `
+		SELECT u.id, u.username, m.created_at
+		FROM mutes m
+		JOIN users u ON u.id = m.muted_id
+		WHERE m.muter_id = $1`
+
+	args := []any{muterID, limit}
+
+	if cursor != nil {
+		query += ` AND (m.created_at, u.id) < ($3, $4)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+
+	query += `
+		ORDER BY m.created_at DESC, u.id DESC
+		LIMIT $2`
+
+	return queryRelatedUsers(ctx, s.db, query, args...)
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -62,24 +63,48 @@ func (p *PaginatedFieldQuery) Parse(r *http.Request) error {
 	since := qs.Get("since")
 
 	if since != "" {
-		p.Since = parseTime(since)
+		t, err := parseTime(since)
+		if err != nil {
+			return errors.New("since must be an RFC 3339 timestamp")
+		}
+		p.Since = t
 	}
 
 	until := qs.Get("until")
 
 	if until != "" {
-		p.Until = parseTime(until)
+		t, err := parseTime(until)
+		if err != nil {
+			return errors.New("until must be an RFC 3339 timestamp")
+		}
+		p.Until = t
 	}
 
 	return nil
 }
 
-func parseTime(s string) string {
+func parseTime(s string) (string, error) {
 	t, err := time.Parse(time.RFC3339, s)
-
 	if err != nil {
-		return ""
+		return "", err
+	}
+
+	return t.UTC().Format(time.RFC3339), nil
+}
+
+// sortDirections maps the sort query parameter to the direction it orders
+// by, so no other value ever reaches a query.
+var sortDirections = map[string]string{
+	"asc":  "ASC",
+	"desc": "DESC",
+}
+
+// direction returns the SQL direction for p.Sort, descending unless it asks
+// for ascending.
+func (p PaginatedFieldQuery) direction() string {
+	if dir, ok := sortDirections[p.Sort]; ok {
+		return dir
 	}
 
-	return t.Format(time.RFC3339)
+	return "DESC"
 }
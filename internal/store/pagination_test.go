@@ -0,0 +1,20 @@
+package store
+
+import "testing"
+
+func TestParseTime(t *testing.T) {
+	got, err := parseTime("2024-05-01T14:30:00+02:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "2024-05-01T12:30:00Z"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, s := range []string{"", "yesterday", "2024-05-01", "2024-05-01 12:30:00", "2024-13-01T12:30:00Z", "1714566600"} {
+		if _, err := parseTime(s); err == nil {
+			t.Errorf("%q: got no error", s)
+		}
+	}
+}
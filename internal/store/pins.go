@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type PinsStore struct {
+	db *sql.DB
+}
+
+// Pin adds postID to the end of userID's pinned posts. It fails with
+// ErrTooManyPins once the user has max pins; pinning twice is a no-op.
+func (s *PinsStore) Pin(ctx context.Context, userID, postID int64, max int) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		// Lock the user row so concurrent pins can't both pass the limit.
+		if _, err := tx.ExecContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+			return err
+		}
+
+		var count, exists int
+
+		query := `
+			SELECT COUNT(*), COUNT(*) FILTER (WHERE pp.post_id = $2)
+			FROM pinned_posts pp
+			JOIN posts p ON p.id = pp.post_id AND p.deleted_at IS NULL
+			WHERE pp.user_id = $1`
+
+		if err := tx.QueryRowContext(ctx, query, userID, postID).Scan(&count, &exists); err != nil {
+			return err
+		}
+
+		if exists > 0 {
+			return nil
+		}
+
+		if count >= max {
+			return ErrTooManyPins
+		}
+
+		query = `
+			INSERT INTO pinned_posts (user_id, post_id, position)
+			SELECT $1, $2, COALESCE(MAX(position) + 1, 0) FROM pinned_posts WHERE user_id = $1`
+
+		_, err := tx.ExecContext(ctx, query, userID, postID)
+
+		return err
+	})
+}
+
+func (s *PinsStore) Unpin(ctx context.Context, userID, postID int64) error {
+	query := `DELETE FROM pinned_posts WHERE user_id = $1 AND post_id = $2`
+
+	_, err := s.db.ExecContext(ctx, query, userID, postID)
+
+	return err
+}
+
+// Reorder sets the pin order to postIDs, which must contain exactly the
+// posts the user has pinned.
+func (s *PinsStore) Reorder(ctx context.Context, userID int64, postIDs []int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var pinned int
+
+		query := `
+			SELECT COUNT(*)
+			FROM pinned_posts pp
+			JOIN posts p ON p.id = pp.post_id AND p.deleted_at IS NULL
+			WHERE pp.user_id = $1`
+
+		if err := tx.QueryRowContext(ctx, query, userID).Scan(&pinned); err != nil {
+			return err
+		}
+
+		query = `
+			UPDATE pinned_posts pp
+			SET position = o.position - 1
+			FROM unnest($2::bigint[]) WITH ORDINALITY AS o(post_id, position)
+			WHERE pp.user_id = $1 AND pp.post_id = o.post_id`
+
+		res, err := tx.ExecContext(ctx, query, userID, pq.Array(postIDs))
+		if err != nil {
+			return err
+		}
+
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if int(updated) != pinned || len(postIDs) != pinned {
+			return ErrInvalidPinOrder
+		}
+
+		return nil
+	})
+}
+
+// GetByUserID returns userID's pinned posts that viewerID is in the
+// audience of, in order.
+func (s *PinsStore) GetByUserID(ctx context.Context, userID, viewerID int64) ([]PostWithMetadata, error) {
+	query := `
+		SELECT p.id, p.user_id, p.title, p.content, p.content_format, p.created_at, p.version, p.tags,
+			u.username, u.email,
+			(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL AND c.status = 'approved') AS comments_count
+		FROM pinned_posts pp
+		JOIN posts p ON p.id = pp.post_id
+		LEFT JOIN users u ON u.id = p.user_id
+		WHERE pp.user_id = $1 AND p.deleted_at IS NULL AND ` + postVisibleTo("$2") + `
+		ORDER BY pp.position`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanPostsWithMetadata(rows)
+}
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type Poll struct {
+	ID             int64        `json:"id"`
+	PostID         int64        `json:"post_id"`
+	MultipleChoice bool         `json:"multiple_choice"`
+	ClosesAt       string       `json:"closes_at"`
+	Closed         bool         `json:"closed"`
+	TotalVoters    int          `json:"total_voters"`
+	Options        []PollOption `json:"options"`
+	MyVotes        []int64      `json:"my_votes"`
+}
+
+type PollOption struct {
+	ID       int64  `json:"id"`
+	Position int    `json:"position"`
+	Text     string `json:"text"`
+	// Votes is nil while results are hidden from the viewer.
+	Votes *int `json:"votes,omitempty"`
+}
+
+// HideResults drops the per-option tallies unless the viewer has voted or
+// the poll is closed.
+func (p *Poll) HideResults() {
+	if p.Closed || len(p.MyVotes) > 0 {
+		return
+	}
+
+	for i := range p.Options {
+		p.Options[i].Votes = nil
+	}
+}
+
+type PollsStore struct {
+	db *sql.DB
+}
+
+// GetByPostID returns the poll attached to a post with live tallies, or the
+// frozen ones once it has been finalized, and viewerID's votes.
+func (s *PollsStore) GetByPostID(ctx context.Context, postID, viewerID int64) (*Poll, error) {
+	query := `
+		SELECT id, post_id, multiple_choice, closes_at, closes_at <= now(),
+			(SELECT COUNT(*) FROM poll_ballots pb WHERE pb.poll_id = polls.id),
+			ARRAY(SELECT pv.option_id FROM poll_votes pv WHERE pv.poll_id = polls.id AND pv.user_id = $2 ORDER BY pv.option_id)
+		FROM polls
+		WHERE post_id = $1`
+
+	poll := &Poll{}
+
+	err := s.db.QueryRowContext(ctx, query, postID, viewerID).Scan(
+		&poll.ID,
+		&poll.PostID,
+		&poll.MultipleChoice,
+		&poll.ClosesAt,
+		&poll.Closed,
+		&poll.TotalVoters,
+		pq.Array(&poll.MyVotes),
+	)
+
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	query = `
+		SELECT po.id, po.position, po.text,
+			CASE WHEN p.finalized_at IS NOT NULL THEN po.votes_count
+				ELSE (SELECT COUNT(*) FROM poll_votes pv WHERE pv.option_id = po.id)
+			END
+		FROM poll_options po
+		JOIN polls p ON p.id = po.poll_id
+		WHERE po.poll_id = $1
+		ORDER BY po.position`
+
+	rows, err := s.db.QueryContext(ctx, query, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	poll.Options = []PollOption{}
+
+	for rows.Next() {
+		option := PollOption{}
+		var votes int
+
+		if err := rows.Scan(&option.ID, &option.Position, &option.Text, &votes); err != nil {
+			return nil, err
+		}
+
+		option.Votes = &votes
+		poll.Options = append(poll.Options, option)
+	}
+
+	return poll, rows.Err()
+}
+
+// Vote records userID's ballot. The ballot's primary key guarantees a single
+// vote per user even under concurrent requests.
+func (s *PollsStore) Vote(ctx context.Context, pollID, userID int64, optionIDs []int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var open bool
+
+		query := `SELECT closes_at > now() FROM polls WHERE id = $1`
+
+		if err := tx.QueryRowContext(ctx, query, pollID).Scan(&open); err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if !open {
+			return ErrPollClosed
+		}
+
+		query = `INSERT INTO poll_ballots (poll_id, user_id) VALUES ($1, $2)`
+
+		if _, err := tx.ExecContext(ctx, query, pollID, userID); err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				return ErrAlreadyVoted
+			}
+			return err
+		}
+
+		query = `INSERT INTO poll_votes (poll_id, user_id, option_id) SELECT $1, $2, unnest($3::bigint[])`
+
+		if _, err := tx.ExecContext(ctx, query, pollID, userID, pq.Array(optionIDs)); err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && (pqErr.Code == "23503" || pqErr.Code == "23505") {
+				return ErrInvalidPollOption
+			}
+			return err
+		}
+
+		return nil
+	})
+}
+
+// FinalizeClosed freezes the tallies of every poll that has closed since the
+// last run and reports how many were finalized.
+func (s *PollsStore) FinalizeClosed(ctx context.Context) (int64, error) {
+	var finalized int64
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE poll_options po
+			SET votes_count = (SELECT COUNT(*) FROM poll_votes pv WHERE pv.option_id = po.id)
+			FROM polls p
+			WHERE p.id = po.poll_id AND p.finalized_at IS NULL AND p.closes_at <= now()`
+
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+
+		query = `UPDATE polls SET finalized_at = now() WHERE finalized_at IS NULL AND closes_at <= now()`
+
+		res, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		finalized, err = res.RowsAffected()
+
+		return err
+	})
+
+	return finalized, err
+}
+
+// createPoll stores post.Poll for a post being created in tx.
+func createPoll(ctx context.Context, tx *sql.Tx, post *Post) error {
+	poll := post.Poll
+	poll.PostID = int64(post.ID)
+
+	query := `INSERT INTO polls (post_id, multiple_choice, closes_at) VALUES ($1, $2, $3) RETURNING id, closes_at`
+
+	err := tx.QueryRowContext(ctx, query, poll.PostID, poll.MultipleChoice, poll.ClosesAt).Scan(
+		&poll.ID,
+		&poll.ClosesAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO poll_options (poll_id, position, text) VALUES ($1, $2, $3) RETURNING id`
+
+	for i := range poll.Options {
+		option := &poll.Options[i]
+		option.Position = i
+
+		if err := tx.QueryRowContext(ctx, query, poll.ID, option.Position, option.Text).Scan(&option.ID); err != nil {
+			return err
+		}
+	}
+
+	poll.MyVotes = []int64{}
+
+	return nil
+}
@@ -4,28 +4,84 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
+	"slices"
+	"social/internal/markdown"
+	"strings"
 
 	"github.com/lib/pq"
 )
 
 type Post struct {
-	ID        int       `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	UserId    int64     `json:"user_id"`
-	Tags      []string  `json:"tags"`
-	CreatedAt string    `json:"created_at"`
-	UpdatedAt string    `json:"updated_at"`
-	Comments  []Comment `json:"comments"`
-	Version   int       `json:"version"`
-	User      User      `json:"user"`
+	ID            int       `json:"id"`
+	Title         string    `json:"title"`
+	Content       string    `json:"content"`
+	ContentFormat string    `json:"content_format"`
+	ContentHTML   string    `json:"content_html"`
+	UserId        int64     `json:"user_id"`
+	Tags          []string  `json:"tags"`
+	CreatedAt     string    `json:"created_at"`
+	UpdatedAt     string    `json:"updated_at"`
+	Comments      []Comment `json:"comments_preview"`
+	// Mentions are stored along with the post: Create and Update resolve
+	// the candidates it holds and replace them with the stored mentions.
+	Mentions      []Mention `json:"mentions"`
+	Version       int       `json:"version"`
+	User          User      `json:"user"`
+	QuoteOfID     *int64    `json:"quote_of_id"`
+	QuotedPost    *Post     `json:"quoted_post,omitempty"`
+	InReplyToID   *int64    `json:"in_reply_to_id"`
+	DeletedAt     *string   `json:"deleted_at,omitempty"`
+	Poll          *Poll     `json:"poll,omitempty"`
+	CommentPolicy string    `json:"comment_policy"`
+	// Audience is public, or list for posts shared with AudienceListID's
+	// members only.
+	Audience       string `json:"audience,omitempty"`
+	AudienceListID *int64 `json:"audience_list_id,omitempty"`
 }
 
+// Comment policies decide who may comment on a post.
+const (
+	CommentPolicyEveryone  = "everyone"
+	CommentPolicyFollowers = "followers"
+	// CommentPolicyMentioned lets only the users mentioned in the post
+	// comment.
+	CommentPolicyMentioned = "mentioned"
+	CommentPolicyNobody    = "nobody"
+	// CommentPolicyApproval holds comments for the author's approval.
+	CommentPolicyApproval = "approval"
+)
+
 type PostWithMetadata struct {
 	Post
 
-	CommentsCount int `json:"comments_count"`
+	CommentsCount int            `json:"comments_count"`
+	Reactions     ReactionCounts `json:"reactions"`
+	MyReactions   []string       `json:"my_reactions"`
+	RepostsCount  int            `json:"reposts_count"`
+	QuotesCount   int            `json:"quotes_count"`
+	// RepostedBy is set when the post shows up in the feed because a
+	// followed user reposted it.
+	RepostedBy *User `json:"reposted_by,omitempty"`
+	// FeedAt is when the post entered the feed: when it was written, or
+	// reposted.
+	FeedAt string `json:"feed_at,omitempty"`
+}
+
+// postVisibleTo is a condition on posts aliased p that holds when the user
+// bound to param may see the post. Authors always see their own posts;
+// anyone else has to be in the post's audience, everyone for public posts
+// and the list's members otherwise, must not share a block with the author
+// and, when the author's account is private, has to be one of their
+// approved followers.
+func postVisibleTo(param string) string {
+	return `(p.user_id = ` + param + ` OR (
+		(p.audience = 'public'
+			OR EXISTS (SELECT 1 FROM audience_list_members alm WHERE alm.list_id = p.audience_list_id AND alm.user_id = ` + param + `))
+		AND (NOT EXISTS (SELECT 1 FROM users pu WHERE pu.id = p.user_id AND pu.is_private)
+			OR EXISTS (SELECT 1 FROM followers pf WHERE pf.user_id = p.user_id AND pf.follower_id = ` + param + `))
+		AND NOT EXISTS (SELECT 1 FROM blocks pb
+			WHERE (pb.blocker_id = ` + param + ` AND pb.blocked_id = p.user_id)
+				OR (pb.blocker_id = p.user_id AND pb.blocked_id = ` + param + `))))`
 }
 
 type PostsStore struct {
@@ -33,15 +89,59 @@ type PostsStore struct {
 }
 
 func (s *PostsStore) Create(ctx context.Context, post *Post) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return insertPost(ctx, tx, post)
+	})
+}
+
+// CreateThread inserts posts in order in a single transaction, each one
+// replying to the post before it. The first post keeps its own
+// InReplyToID so a thread can continue an existing conversation.
+func (s *PostsStore) CreateThread(ctx context.Context, posts []*Post) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		for i, post := range posts {
+			if i > 0 {
+				parentID := int64(posts[i-1].ID)
+				post.InReplyToID = &parentID
+			}
+
+			if err := insertPost(ctx, tx, post); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func insertPost(ctx context.Context, tx *sql.Tx, post *Post) error {
 	// Create a new post
-	query := `INSERT INTO posts (title, content, user_id, tags) VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
+	query := `INSERT INTO posts (title, content, content_format, user_id, tags, quote_of_id, in_reply_to_id, comment_policy, audience, audience_list_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at, updated_at`
+
+	if post.ContentFormat == "" {
+		post.ContentFormat = markdown.FormatPlain
+	}
+
+	if post.CommentPolicy == "" {
+		post.CommentPolicy = CommentPolicyEveryone
+	}
+
+	if post.Audience == "" {
+		post.Audience = PostAudiencePublic
+	}
 
-	err := s.db.QueryRowContext(ctx,
+	err := tx.QueryRowContext(ctx,
 		query,
 		post.Title,
 		post.Content,
+		post.ContentFormat,
 		post.UserId,
 		pq.Array(post.Tags),
+		post.QuoteOfID,
+		post.InReplyToID,
+		post.CommentPolicy,
+		post.Audience,
+		post.AudienceListID,
 	).Scan(
 		&post.ID,
 		&post.CreatedAt,
@@ -51,24 +151,63 @@ func (s *PostsStore) Create(ctx context.Context, post *Post) error {
 		return err
 	}
 
-	return nil
+	if post.Poll != nil {
+		if err := createPoll(ctx, tx, post); err != nil {
+			return err
+		}
+	}
+
+	post.Mentions, err = replaceMentions(ctx, tx, int64(post.ID), nil, post.UserId, post.Mentions)
+	if err != nil {
+		return err
+	}
+
+	return setPostTags(ctx, tx, post.ID, post.Tags)
+}
+
+// GetById returns the post if viewerID may see it; posts shared with a list
+// the viewer isn't on, written by a private account they don't follow, or by
+// someone they share a block with, are reported as not found. Only the author gets to see which list a post
+// is shared with.
+func (s *PostsStore) GetById(ctx context.Context, id int, viewerID int64) (*Post, error) {
+	return s.getById(ctx, id, viewerID, postVisibleTo("$2"))
+}
+
+// GetByIdForModeration returns the post whether or not viewerID may see it,
+// for moderators acting on it. Deleted posts are still reported as not
+// found.
+func (s *PostsStore) GetByIdForModeration(ctx context.Context, id int, viewerID int64) (*Post, error) {
+	return s.getById(ctx, id, viewerID, "TRUE")
 }
 
-func (s *PostsStore) GetById(ctx context.Context, id int) (*Post, error) {
+// getById returns the live post id when visible holds for it, a condition on
+// posts aliased p in which viewerID is bound to $2.
+func (s *PostsStore) getById(ctx context.Context, id int, viewerID int64, visible string) (*Post, error) {
 	// Get post by id
-	query := `SELECT id, title, content, user_id, tags, created_at, updated_at, version FROM posts WHERE id = $1`
+	query := `
+		SELECT p.id, p.title, p.content, p.content_format, p.user_id, p.tags, p.created_at, p.updated_at, p.version,
+			p.quote_of_id, p.in_reply_to_id, p.comment_policy, p.audience,
+			CASE WHEN p.user_id = $2 THEN p.audience_list_id END
+		FROM posts p
+		WHERE p.id = $1 AND p.deleted_at IS NULL AND ` + visible
 
 	post := &Post{}
 
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
+	err := s.db.QueryRowContext(ctx, query, id, viewerID).Scan(
 		&post.ID,
 		&post.Title,
 		&post.Content,
+		&post.ContentFormat,
 		&post.UserId,
 		pq.Array(&post.Tags),
 		&post.CreatedAt,
 		&post.UpdatedAt,
 		&post.Version,
+		&post.QuoteOfID,
+		&post.InReplyToID,
+		&post.CommentPolicy,
+		&post.Audience,
+		&post.AudienceListID,
 	)
 
 	if err != nil {
@@ -84,8 +223,9 @@ func (s *PostsStore) GetById(ctx context.Context, id int) (*Post, error) {
 }
 
 func (s *PostsStore) Delete(ctx context.Context, id int) error {
-	// Delete post by id
-	query := `DELETE FROM posts WHERE id = $1`
+	// Soft delete the post; it stays restorable until the retention job
+	// purges it.
+	query := `UPDATE posts SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`
 
 	res, err := s.db.ExecContext(ctx, query, id)
 
@@ -110,78 +250,184 @@ func (s *PostsStore) Update(ctx context.Context, post *Post) error {
 	// Update post
 	query := `
 		UPDATE posts 
-		SET title = $1, content = $2, updated_at = now(), version = version + 1 
-		WHERE id = $3 AND version = $4
+		SET title = $1, content = $2, content_format = $3, tags = $4, comment_policy = $7, updated_at = now(), version = version + 1 
+		WHERE id = $5 AND version = $6 AND deleted_at IS NULL
 		RETURNING version`
 
-	err := s.db.QueryRowContext(
-		ctx,
-		query,
-		post.Title,
-		post.Content,
-		post.ID,
-		post.Version,
-	).Scan(&post.Version)
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			post.Title,
+			post.Content,
+			post.ContentFormat,
+			pq.Array(post.Tags),
+			post.ID,
+			post.Version,
+			post.CommentPolicy,
+		).Scan(&post.Version)
 
-	if err != nil {
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			return ErrNotFound
-		default:
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		post.Mentions, err = replaceMentions(ctx, tx, int64(post.ID), nil, post.UserId, post.Mentions)
+		if err != nil {
 			return err
 		}
-	}
 
-	return nil
+		return setPostTags(ctx, tx, post.ID, post.Tags)
+	})
 }
 
-func (s *PostsStore) GetUserFeed(ctx context.Context, userId int64, fq PaginatedFieldQuery) ([]PostWithMetadata, error) {
-	//TODO : implement time sorting
+// Feed sort orders. Ties are broken by when posts entered the feed.
+const (
+	FeedSortNewest = "newest"
+	FeedSortOldest = "oldest"
+	// FeedSortMostCommented ranks posts by their approved comments.
+	FeedSortMostCommented = "most_commented"
+	// FeedSortMostReacted ranks posts by their reactions of any kind.
+	FeedSortMostReacted = "most_reacted"
+)
+
+// feedOrder describes how a feed sort orders its items: by rank, when it
+// has one, then by (feed_at, id), all in the same direction.
+type feedOrder struct {
+	rank string
+	asc  bool
+}
+
+var feedOrders = map[string]feedOrder{
+	FeedSortNewest:        {},
+	FeedSortOldest:        {asc: true},
+	FeedSortMostCommented: {rank: `COUNT(c.id)`},
+	FeedSortMostReacted:   {rank: `(SELECT COUNT(*) FROM post_reactions pr WHERE pr.post_id = p.id)`},
+}
+
+// GetUserFeed lists the posts and reposts of userId and the users they
+// follow, ordered by fq.Sort, one of the FeedSort orders, and limited to
+// those entering the feed between fq.Since and fq.Until. With a cursor the
+// page starts next to it, in the direction it points, and fq.Offset is
+// ignored.
+func (s *PostsStore) GetUserFeed(ctx context.Context, userId int64, fq PaginatedFieldQuery, cursor *Cursor) ([]PostWithMetadata, error) {
+	order, ok := feedOrders[fq.Sort]
+	if !ok {
+		order = feedOrders[FeedSortNewest]
+	}
 
-	baseQuery := `
-        SELECT p.id, p.user_id, p.title, p.content, p.created_at, p.version, p.tags,
+	var b queryBuilder
+
+	// The viewer is always $1.
+	b.Arg(userId)
+
+	// A feed item is either a post written by the user or someone they
+	// follow, or a repost made by one of them. A post shows up once, as the
+	// item that brought it into the feed first, so reposts of a post that
+	// is already there, or reposted by several users, don't repeat it.
+	b.Write(`
+        WITH feed_items AS (
+           SELECT DISTINCT ON (fi.post_id) fi.post_id, fi.actor_id, fi.feed_at, fi.is_repost
+           FROM (
+              SELECT p.id AS post_id, p.user_id AS actor_id, p.created_at AS feed_at, FALSE AS is_repost
+              FROM posts p
+              UNION ALL
+              SELECT r.post_id, r.user_id, r.created_at, TRUE
+              FROM reposts r
+           ) fi
+           WHERE (fi.actor_id = $1 OR EXISTS (SELECT 1 FROM followers f WHERE f.user_id = fi.actor_id AND f.follower_id = $1))
+              AND NOT EXISTS (SELECT 1 FROM blocks b
+                 WHERE (b.blocker_id = $1 AND b.blocked_id = fi.actor_id) OR (b.blocked_id = $1 AND b.blocker_id = fi.actor_id))
+              AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.muter_id = $1 AND m.muted_id = fi.actor_id)
+           ORDER BY fi.post_id, fi.feed_at, fi.is_repost, fi.actor_id
+        )
+        SELECT p.id, p.user_id, p.title, p.content, p.content_format, p.created_at, p.version, p.tags,
            u.username, u.email,
-           COUNT(c.id) as comments_count
-        FROM posts p
-        LEFT JOIN comments c ON p.id = c.post_id
+           COUNT(c.id) as comments_count,
+           (SELECT json_object_agg(rc.kind, rc.n)
+              FROM (SELECT pr.kind, COUNT(*) AS n FROM post_reactions pr WHERE pr.post_id = p.id GROUP BY pr.kind) rc
+           ) AS reactions,
+           ARRAY(SELECT pr.kind FROM post_reactions pr WHERE pr.post_id = p.id AND pr.user_id = $1 ORDER BY pr.kind) AS my_reactions,
+           (SELECT COUNT(*) FROM reposts r WHERE r.post_id = p.id) AS reposts_count,
+           (SELECT COUNT(*) FROM posts q WHERE q.quote_of_id = p.id AND q.deleted_at IS NULL) AS quotes_count,
+           p.quote_of_id, fi.is_repost, a.id, a.username, fi.feed_at
+        FROM feed_items fi
+        JOIN posts p ON p.id = fi.post_id
+        LEFT JOIN comments c ON p.id = c.post_id AND c.deleted_at IS NULL AND c.status = 'approved'
         LEFT JOIN users u ON p.user_id = u.id
-        LEFT JOIN followers f ON f.user_id = p.user_id AND f.follower_id = $1
-        WHERE (p.user_id = $1 OR f.follower_id IS NOT NULL)`
+        LEFT JOIN users a ON a.id = fi.actor_id
+        WHERE p.deleted_at IS NULL
+           AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.muter_id = $1 AND m.muted_id = p.user_id)
+           AND `, postVisibleTo("$1"))
 
 	if fq.Search != "" {
-		baseQuery += ` AND (p.title ILIKE '%' || $4 || '%' OR p.content ILIKE '%' || $4 || '%')`
+		search := b.Arg(fq.Search)
+		b.Write(` AND (p.title ILIKE '%' || `, search, ` || '%' OR p.content ILIKE '%' || `, search, ` || '%')`)
 	}
 
 	if len(fq.Tags) > 0 {
-		baseQuery += ` AND (p.tags @> $5::text[])`
-	}
-
-	baseQuery += `
-        GROUP BY p.id, p.user_id, p.title, p.content, p.created_at, p.version, p.tags, 
-        u.username, u.email
-        ORDER BY p.created_at ` + fq.Sort + `
-        LIMIT $2 OFFSET $3`
-
-	var rows *sql.Rows
-	var err error
-
-	// Debug logging before executing query
-	if fq.Search != "" && len(fq.Tags) > 0 {
-		fmt.Println("Executing search and tags query:")
-		fmt.Println("SQL:", baseQuery)
-		fmt.Println("Params:", userId, fq.Limit, fq.Offset, fq.Search, fq.Tags)
-		rows, err = s.db.QueryContext(ctx, baseQuery, userId, fq.Limit, fq.Offset, fq.Search, pq.Array(fq.Tags))
-	} else if fq.Search != "" {
-		fmt.Println("Executing search query:")
-		fmt.Println("SQL:", baseQuery)
-		fmt.Println("Params:", userId, fq.Limit, fq.Offset, fq.Search)
-		rows, err = s.db.QueryContext(ctx, baseQuery, userId, fq.Limit, fq.Offset, fq.Search)
-	} else if len(fq.Tags) > 0 {
-		rows, err = s.db.QueryContext(ctx, baseQuery, userId, fq.Limit, fq.Offset, pq.Array(fq.Tags))
-	} else {
-		rows, err = s.db.QueryContext(ctx, baseQuery, userId, fq.Limit, fq.Offset)
+		b.Write(` AND (p.tags @> `, b.Arg(pq.Array(fq.Tags)), `::text[])`)
 	}
 
+	if fq.Since != "" {
+		b.Write(` AND fi.feed_at >= `, b.Arg(fq.Since))
+	}
+
+	if fq.Until != "" {
+		b.Write(` AND fi.feed_at <= `, b.Arg(fq.Until))
+	}
+
+	b.Write(`
+        GROUP BY p.id, p.user_id, p.title, p.content, p.content_format, p.created_at, p.version, p.tags, 
+        u.username, u.email, fi.actor_id, fi.feed_at, fi.is_repost, a.id, a.username`)
+
+	keys := []string{`fi.feed_at`, `p.id`}
+	if order.rank != "" {
+		keys = append([]string{order.rank}, keys...)
+	}
+
+	// A cursor pointing backwards walks the feed in the opposite order; the
+	// rows are put back in feed order once read.
+	backward := cursor != nil && cursor.Before
+	asc := order.asc != backward
+
+	if cursor != nil {
+		cmp := ` < `
+		if asc {
+			cmp = ` > `
+		}
+
+		values := []string{}
+		if order.rank != "" {
+			values = append(values, b.Arg(cursor.Rank))
+		}
+		values = append(values, b.Arg(cursor.CreatedAt), b.Arg(cursor.ID))
+
+		// The rank may be an aggregate, so the position is checked once
+		// the feed items are grouped.
+		b.Write(`
+        HAVING (`, strings.Join(keys, ", "), `)`, cmp, `(`, strings.Join(values, ", "), `)`)
+	}
+
+	dir := ` DESC`
+	if asc {
+		dir = ` ASC`
+	}
+
+	b.Write(`
+        ORDER BY `, strings.Join(keys, dir+", "), dir, `
+        LIMIT `, b.Arg(fq.Limit))
+
+	if cursor == nil {
+		b.Write(` OFFSET `, b.Arg(fq.Offset))
+	}
+
+	rows, err := s.db.QueryContext(ctx, b.String(), b.Args()...)
+
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
@@ -197,18 +443,30 @@ func (s *PostsStore) GetUserFeed(ctx context.Context, userId int64, fq Paginated
 
 	for rows.Next() {
 		post := PostWithMetadata{}
+		reposter := User{}
+		var isRepost bool
 
 		err := rows.Scan(
 			&post.ID,
 			&post.UserId,
 			&post.Title,
 			&post.Content,
+			&post.ContentFormat,
 			&post.CreatedAt,
 			&post.Version,
 			pq.Array(&post.Tags),
 			&post.User.Username,
 			&post.User.Email,
 			&post.CommentsCount,
+			&post.Reactions,
+			pq.Array(&post.MyReactions),
+			&post.RepostsCount,
+			&post.QuotesCount,
+			&post.QuoteOfID,
+			&isRepost,
+			&reposter.ID,
+			&reposter.Username,
+			&post.FeedAt,
 		)
 
 		if err != nil {
@@ -220,8 +478,133 @@ func (s *PostsStore) GetUserFeed(ctx context.Context, userId int64, fq Paginated
 			}
 		}
 
+		if isRepost {
+			post.RepostedBy = &reposter
+		}
+
 		posts = append(posts, post)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if backward {
+		slices.Reverse(posts)
+	}
+
 	return posts, nil
 }
+
+// FilterVisible reports which of ids are live posts viewerID may see, for
+// lists that are computed ahead of time for every viewer.
+func (s *PostsStore) FilterVisible(ctx context.Context, viewerID int64, ids []int64) (map[int64]bool, error) {
+	query := `
+		SELECT p.id FROM posts p
+		WHERE p.id = ANY($1) AND p.deleted_at IS NULL AND ` + postVisibleTo("$2")
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids), viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	visible := map[int64]bool{}
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		visible[id] = true
+	}
+
+	return visible, rows.Err()
+}
+
+// FeedCursor returns the cursor continuing after post in a feed sorted by
+// sort.
+func FeedCursor(post PostWithMetadata, sort string) (Cursor, error) {
+	cursor, err := NewCursor(post.FeedAt, int64(post.ID))
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	switch sort {
+	case FeedSortMostCommented:
+		cursor.Rank = int64(post.CommentsCount)
+	case FeedSortMostReacted:
+		for _, n := range post.Reactions {
+			cursor.Rank += int64(n)
+		}
+	}
+
+	return cursor, nil
+}
+
+// GetByUserID lists the posts written by userID, newest first, starting
+// after the given cursor when there is one.
+func (s *PostsStore) GetByUserID(ctx context.Context, userID, viewerID int64, cursor *Cursor, limit int) ([]PostWithMetadata, error) {
+	query := `
+		SELECT p.id, p.user_id, p.title, p.content, p.content_format, p.created_at, p.version, p.tags,
+			u.username, u.email,
+			(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL AND c.status = 'approved') AS comments_count
+		FROM posts p
+		LEFT JOIN users u ON u.id = p.user_id
+		WHERE p.user_id = $1 AND p.deleted_at IS NULL AND ` + postVisibleTo("$3")
+
+	args := []any{userID, limit, viewerID}
+
+	if cursor != nil {
+		query += ` AND (p.created_at, p.id) < ($4, $5)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+
+	query += `
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanPostsWithMetadata(rows)
+}
+
+// scanPostsWithMetadata reads rows selecting a post, its author's username
+// and email, and its comment count.
+func scanPostsWithMetadata(rows *sql.Rows) ([]PostWithMetadata, error) {
+	posts := []PostWithMetadata{}
+
+	for rows.Next() {
+		post := PostWithMetadata{}
+
+		err := rows.Scan(
+			&post.ID,
+			&post.UserId,
+			&post.Title,
+			&post.Content,
+			&post.ContentFormat,
+			&post.CreatedAt,
+			&post.Version,
+			pq.Array(&post.Tags),
+			&post.User.Username,
+			&post.User.Email,
+			&post.CommentsCount,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
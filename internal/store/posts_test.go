@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// newTestStorage connects to the migrated database at TEST_DB_ADDR, skipping
+// the test when none is configured.
+func newTestStorage(t *testing.T) (*Storage, *sql.DB) {
+	t.Helper()
+
+	addr := os.Getenv("TEST_DB_ADDR")
+	if addr == "" {
+		t.Skip("TEST_DB_ADDR not set")
+	}
+
+	db, err := sql.Open("postgres", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return NewStorage(db), db
+}
+
+// createTestUser inserts a user whose rows are removed when the test ends.
+func createTestUser(t *testing.T, db *sql.DB, private bool) int64 {
+	t.Helper()
+
+	ctx := context.Background()
+	name := fmt.Sprintf("test%d", time.Now().UnixNano())
+
+	var id int64
+
+	query := `
+		INSERT INTO users (username, email, password, role_id, is_private)
+		VALUES ($1, $1 || '@example.com', '', (SELECT id FROM roles WHERE name = 'user'), $2)
+		RETURNING id`
+
+	if err := db.QueryRowContext(ctx, query, name, private).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if _, err := db.ExecContext(ctx, `DELETE FROM posts WHERE user_id = $1`, id); err != nil {
+			t.Errorf("deleting posts of user %d: %v", id, err)
+		}
+
+		if _, err := db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id); err != nil {
+			t.Errorf("deleting user %d: %v", id, err)
+		}
+	})
+
+	return id
+}
+
+func TestGetByIdHidesPrivateAccountPosts(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, true)
+	follower := createTestUser(t, db, false)
+	stranger := createTestUser(t, db, false)
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO followers (user_id, follower_id) VALUES ($1, $2)`, author, follower); err != nil {
+		t.Fatal(err)
+	}
+
+	post := &Post{Title: "private", Content: "only for followers", UserId: author, Tags: []string{}}
+
+	if err := s.Posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Posts.GetById(ctx, post.ID, stranger); !errors.Is(err, ErrNotFound) {
+		t.Errorf("non-follower: got %v, want ErrNotFound", err)
+	}
+
+	for name, viewer := range map[string]int64{"author": author, "follower": follower} {
+		if _, err := s.Posts.GetById(ctx, post.ID, viewer); err != nil {
+			t.Errorf("%s: got %v, want the post", name, err)
+		}
+	}
+}
+
+func TestGetByIdHidesPostsAcrossBlocks(t *testing.T) {
+	s, db := newTestStorage(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, false)
+	blocker := createTestUser(t, db, false)
+	blocked := createTestUser(t, db, false)
+
+	post := &Post{Title: "public", Content: "for everyone", UserId: author, Tags: []string{}}
+
+	if err := s.Posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Blocks.Block(ctx, blocker, author); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Blocks.Block(ctx, author, blocked); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, viewer := range map[string]int64{"blocker": blocker, "blocked": blocked} {
+		if _, err := s.Posts.GetById(ctx, post.ID, viewer); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: got %v, want ErrNotFound", name, err)
+		}
+	}
+}
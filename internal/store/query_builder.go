@@ -0,0 +1,35 @@
+package store
+
+import (
+	"strconv"
+	"strings"
+)
+
+// queryBuilder assembles a query out of optional clauses, numbering the
+// parameters they bind in the order they are bound.
+type queryBuilder struct {
+	query strings.Builder
+	args  []any
+}
+
+// Arg binds v and returns its placeholder.
+func (b *queryBuilder) Arg(v any) string {
+	b.args = append(b.args, v)
+
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// Write appends parts to the query.
+func (b *queryBuilder) Write(parts ...string) {
+	for _, part := range parts {
+		b.query.WriteString(part)
+	}
+}
+
+func (b *queryBuilder) String() string {
+	return b.query.String()
+}
+
+func (b *queryBuilder) Args() []any {
+	return b.args
+}
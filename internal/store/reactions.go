@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ReactionKinds lists the reactions a user can leave on a post. Keep in sync
+// with the post_reactions_kind constraint.
+var ReactionKinds = []string{"like", "love", "haha", "wow", "sad", "angry"}
+
+type Reaction struct {
+	PostID    int64  `json:"post_id"`
+	UserID    int64  `json:"user_id"`
+	Kind      string `json:"kind"`
+	CreatedAt string `json:"created_at"`
+	User      User   `json:"user"`
+}
+
+// ReactionCounts maps a reaction kind to how many users left it. It scans
+// from a JSON object built in SQL.
+type ReactionCounts map[string]int
+
+func (c *ReactionCounts) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case nil:
+		*c = ReactionCounts{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ReactionCounts", src)
+	}
+
+	counts := ReactionCounts{}
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return err
+	}
+
+	*c = counts
+	return nil
+}
+
+type ReactionsStore struct {
+	db *sql.DB
+}
+
+func (s *ReactionsStore) Add(ctx context.Context, postID, userID int64, kind string) error {
+	query := `INSERT INTO post_reactions (post_id, user_id, kind) VALUES ($1, $2, $3)`
+
+	_, err := s.db.ExecContext(ctx, query, postID, userID, kind)
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrAlreadyReacted
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *ReactionsStore) Remove(ctx context.Context, postID, userID int64, kind string) error {
+	query := `DELETE FROM post_reactions WHERE post_id = $1 AND user_id = $2 AND kind = $3`
+
+	_, err := s.db.ExecContext(ctx, query, postID, userID, kind)
+
+	return err
+}
+
+// GetByPostID lists who reacted to a post, optionally only with kind.
+func (s *ReactionsStore) GetByPostID(ctx context.Context, postID int64, kind string, fq PaginatedFieldQuery) ([]Reaction, error) {
+	query := `
+		SELECT pr.post_id, pr.user_id, pr.kind, pr.created_at, u.id, u.username
+		FROM post_reactions pr
+		JOIN users u ON u.id = pr.user_id
+		WHERE pr.post_id = $1 AND ($2 = '' OR pr.kind = $2)
+		ORDER BY pr.created_at ` + fq.direction() + `, pr.user_id
+		LIMIT $3 OFFSET $4`
+
+	rows, err := s.db.QueryContext(ctx, query, postID, kind, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	reactions := []Reaction{}
+
+	for rows.Next() {
+		reaction := Reaction{}
+
+		err := rows.Scan(
+			&reaction.PostID,
+			&reaction.UserID,
+			&reaction.Kind,
+			&reaction.CreatedAt,
+			&reaction.User.ID,
+			&reaction.User.Username,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		reactions = append(reactions, reaction)
+	}
+
+	return reactions, rows.Err()
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type RepostsStore struct {
+	db *sql.DB
+}
+
+func (s *RepostsStore) Repost(ctx context.Context, postID, userID int64) error {
+	query := `INSERT INTO reposts (user_id, post_id) VALUES ($1, $2)`
+
+	_, err := s.db.ExecContext(ctx, query, userID, postID)
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrAlreadyReposted
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *RepostsStore) Unrepost(ctx context.Context, postID, userID int64) error {
+	query := `DELETE FROM reposts WHERE user_id = $1 AND post_id = $2`
+
+	_, err := s.db.ExecContext(ctx, query, userID, postID)
+
+	return err
+}
@@ -10,10 +10,19 @@ import (
 type Storage struct {
 	Posts interface {
 		Create(context.Context, *Post) error
-		GetById(context.Context, int) (*Post, error)
+		GetById(ctx context.Context, id int, viewerID int64) (*Post, error)
+		GetByIdForModeration(ctx context.Context, id int, viewerID int64) (*Post, error)
 		Delete(context.Context, int) error
 		Update(context.Context, *Post) error
-		GetUserFeed(context.Context, int64, PaginatedFieldQuery) ([]PostWithMetadata, error)
+		GetUserFeed(context.Context, int64, PaginatedFieldQuery, *Cursor) ([]PostWithMetadata, error)
+		GetByUserID(ctx context.Context, userID, viewerID int64, cursor *Cursor, limit int) ([]PostWithMetadata, error)
+		CreateThread(context.Context, []*Post) error
+		GetThread(ctx context.Context, postID, viewerID int64, limit int) (*Thread, error)
+		FilterVisible(ctx context.Context, viewerID int64, ids []int64) (map[int64]bool, error)
+		GetDeletedByID(context.Context, int) (*Post, error)
+		Restore(context.Context, int) error
+		GetDeletedByUserID(context.Context, int64, PaginatedFieldQuery) ([]Post, error)
+		Purge(ctx context.Context, before time.Time) (int64, error)
 	}
 
 	Users interface {
@@ -23,29 +32,161 @@ type Storage struct {
 		Activate(ctx context.Context, token string) error
 		Delete(ctx context.Context, id int64) error
 		GetByEmail(ctx context.Context, email string) (*User, error)
+		GetStats(ctx context.Context, userID, viewerID int64) (*UserStats, error)
+		SetPrivate(ctx context.Context, userID int64, private bool) ([]int64, error)
 	}
 
 	Comments interface {
-		GetByPostID(context.Context, int) ([]Comment, error)
+		GetByPostID(ctx context.Context, postID int, viewerID int64, q CommentTreeQuery) ([]Comment, error)
+		GetByID(context.Context, int) (*Comment, error)
 		Create(context.Context, *Comment) error
+		Update(context.Context, *Comment) error
+		SetHidden(ctx context.Context, id int, hidden bool) error
+		GetPending(ctx context.Context, postID int, fq PaginatedFieldQuery) ([]Comment, error)
+		Approve(context.Context, *Comment) error
+		Delete(context.Context, int) error
+		GetDeletedByID(context.Context, int) (*Comment, error)
+		Restore(context.Context, int) error
+		GetDeletedByUserID(context.Context, int64, PaginatedFieldQuery) ([]Comment, error)
+		Purge(ctx context.Context, before time.Time) (int64, error)
 	}
 
 	Followers interface {
 		Follow(ctx context.Context, followerId, userID int64) error
 		Unfollow(ctx context.Context,followerId, userId int64) error
+		IsFollowing(ctx context.Context, followerID, userID int64) (bool, error)
+		GetFollowers(ctx context.Context, userID, viewerID int64, cursor *Cursor, limit int) ([]FollowListEntry, error)
+		GetFollowing(ctx context.Context, userID, viewerID int64, cursor *Cursor, limit int) ([]FollowListEntry, error)
+		ExportFollowers(ctx context.Context, userID int64) ([]FollowExportEntry, error)
+		ExportFollowing(ctx context.Context, userID int64) ([]FollowExportEntry, error)
 	}
 
 	Roles interface {
 		GetByName(context.Context, string) (*Role, error)
 	}
+
+	Mentions interface {
+		GetByPostID(context.Context, int64) ([]Mention, error)
+		GetByUserID(context.Context, int64, PaginatedFieldQuery) ([]MentionWithContext, error)
+	}
+
+	Tags interface {
+		GetByName(context.Context, string) (*Tag, error)
+		Search(ctx context.Context, prefix string, limit int) ([]Tag, error)
+		GetPosts(context.Context, string, int64, PaginatedFieldQuery) ([]PostWithMetadata, error)
+	}
+
+	Trending interface {
+		ComputeTags(ctx context.Context, window time.Duration, limit int) ([]TrendingTag, error)
+		ComputePosts(ctx context.Context, window time.Duration, limit int) ([]TrendingPost, error)
+		SaveSnapshot(ctx context.Context, window string, tags []TrendingTag, posts []TrendingPost) error
+		GetTags(ctx context.Context, window string, limit int) ([]TrendingTag, error)
+		GetPosts(ctx context.Context, window string, limit int) ([]TrendingPost, error)
+	}
+
+	Reactions interface {
+		Add(ctx context.Context, postID, userID int64, kind string) error
+		Remove(ctx context.Context, postID, userID int64, kind string) error
+		GetByPostID(ctx context.Context, postID int64, kind string, fq PaginatedFieldQuery) ([]Reaction, error)
+	}
+
+	Reposts interface {
+		Repost(ctx context.Context, postID, userID int64) error
+		Unrepost(ctx context.Context, postID, userID int64) error
+	}
+
+	Bookmarks interface {
+		Save(context.Context, *Bookmark) error
+		Remove(ctx context.Context, userID, postID int64) error
+		GetByUserID(ctx context.Context, userID int64, collectionID *int64, fq PaginatedFieldQuery) ([]BookmarkedPost, error)
+		CreateCollection(context.Context, *BookmarkCollection) error
+		GetCollection(ctx context.Context, id, userID int64) (*BookmarkCollection, error)
+		GetCollections(ctx context.Context, userID int64) ([]BookmarkCollection, error)
+		RenameCollection(context.Context, *BookmarkCollection) error
+		DeleteCollection(ctx context.Context, id, userID int64) error
+	}
+
+	Pins interface {
+		Pin(ctx context.Context, userID, postID int64, max int) error
+		Unpin(ctx context.Context, userID, postID int64) error
+		Reorder(ctx context.Context, userID int64, postIDs []int64) error
+		GetByUserID(ctx context.Context, userID, viewerID int64) ([]PostWithMetadata, error)
+	}
+
+	Polls interface {
+		GetByPostID(ctx context.Context, postID, viewerID int64) (*Poll, error)
+		Vote(ctx context.Context, pollID, userID int64, optionIDs []int64) error
+		FinalizeClosed(context.Context) (int64, error)
+	}
+
+	FollowRequests interface {
+		Create(context.Context, *FollowRequest) error
+		GetIncoming(ctx context.Context, userID int64, cursor *Cursor, limit int) ([]FollowRequest, error)
+		Approve(ctx context.Context, userID, requesterID int64) (bool, error)
+		Delete(ctx context.Context, userID, requesterID int64) error
+	}
+
+	Blocks interface {
+		Block(ctx context.Context, blockerID, blockedID int64) error
+		Unblock(ctx context.Context, blockerID, blockedID int64) error
+		IsBlocked(ctx context.Context, userID, otherID int64) (bool, error)
+		GetBlocked(ctx context.Context, blockerID int64, cursor *Cursor, limit int) ([]RelatedUser, error)
+	}
+
+	Mutes interface {
+		Mute(ctx context.Context, muterID, mutedID int64) error
+		Unmute(ctx context.Context, muterID, mutedID int64) error
+		GetMuted(ctx context.Context, muterID int64, cursor *Cursor, limit int) ([]RelatedUser, error)
+	}
+
+	Suggestions interface {
+		Compute(ctx context.Context, activityWindow time.Duration, perUser int) (int64, error)
+		GetByUserID(ctx context.Context, userID int64, limit int) ([]Suggestion, error)
+	}
+
+	FollowImports interface {
+		Create(ctx context.Context, imp *FollowImport, rows []FollowImportRow) error
+		GetByID(ctx context.Context, id, userID int64) (*FollowImport, error)
+		Claim(context.Context) (*FollowImport, error)
+		ProcessBatch(ctx context.Context, imp *FollowImport, size int, limit FollowRateLimit) ([]FollowImportRow, bool, error)
+		Defer(ctx context.Context, id int64, until time.Time) error
+		Complete(ctx context.Context, id int64) error
+	}
+
+	AudienceLists interface {
+		Create(context.Context, *AudienceList) error
+		GetByID(ctx context.Context, id, userID int64) (*AudienceList, error)
+		GetByUserID(ctx context.Context, userID int64) ([]AudienceList, error)
+		Rename(context.Context, *AudienceList) error
+		Delete(ctx context.Context, id, userID int64) error
+		AddMember(ctx context.Context, listID, userID int64) error
+		RemoveMember(ctx context.Context, listID, userID int64) error
+		GetMembers(ctx context.Context, listID int64, cursor *Cursor, limit int) ([]RelatedUser, error)
+	}
 }
 
 var (
 	ErrNotFound = errors.New("resource not found")
 	ErrAlreadyFollowing = errors.New("already following")
 	ErrNotFollowing = errors.New("not following")
+	ErrSelfFollow = errors.New("you can't follow yourself")
+	ErrUserNotFound = errors.New("user not found")
 	ErrDuplicateUsername = errors.New("duplicate username")
 	ErrDuplicateEmail = errors.New("duplicate email")
+	ErrAlreadyReacted = errors.New("already reacted")
+	ErrAlreadyReposted = errors.New("already reposted")
+	ErrDuplicateCollection = errors.New("duplicate collection name")
+	ErrTooManyPins = errors.New("pinned posts limit reached")
+	ErrInvalidPinOrder = errors.New("order must list every pinned post exactly once")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrPollClosed = errors.New("poll is closed")
+	ErrAlreadyVoted = errors.New("already voted")
+	ErrInvalidPollOption = errors.New("invalid poll option")
+	ErrFollowRequestExists = errors.New("follow request already sent")
+	ErrAlreadyBlocked = errors.New("already blocked")
+	ErrAlreadyMuted = errors.New("already muted")
+	ErrDuplicateAudienceList = errors.New("duplicate audience list name")
+	ErrInvalidBatchSize = errors.New("batch size must be positive")
 )
 
 func NewStorage(db *sql.DB) *Storage {
@@ -55,6 +196,20 @@ func NewStorage(db *sql.DB) *Storage {
 		Comments : &CommentsStore{db},
 		Followers : &FollowersStore{db},
 		Roles : &RolesStore{db},
+		Mentions : &MentionsStore{db},
+		Tags : &TagsStore{db},
+		Trending : &TrendingStore{db},
+		Reactions : &ReactionsStore{db},
+		Reposts : &RepostsStore{db},
+		Bookmarks : &BookmarksStore{db},
+		Pins : &PinsStore{db},
+		Polls : &PollsStore{db},
+		FollowRequests : &FollowRequestsStore{db},
+		Blocks : &BlocksStore{db},
+		Mutes : &MutesStore{db},
+		Suggestions : &SuggestionsStore{db},
+		FollowImports : &FollowImportsStore{db},
+		AudienceLists : &AudienceListsStore{db},
 	}
 }
 
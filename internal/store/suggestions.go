@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Suggestion is a user the viewer may want to follow, with the signals
+// behind it.
+type Suggestion struct {
+	User             User    `json:"user"`
+	Score            float64 `json:"score"`
+	MutualCount      int     `json:"mutual_count"`
+	SharedTagsCount  int     `json:"shared_tags_count"`
+	RecentPostsCount int     `json:"recent_posts_count"`
+}
+
+type SuggestionsStore struct {
+	db *sql.DB
+}
+
+// suggestionTagsPerUser is how many of a user's most used tags count
+// towards shared tags.
+const suggestionTagsPerUser = 10
+
+// recentlyActiveUsers selects the users who posted, followed someone or
+// signed up within the last $1 seconds.
+const recentlyActiveUsers = `
+	SELECT p.user_id FROM posts p WHERE p.created_at >= now() - $1 * interval '1 second'
+	UNION
+	SELECT f.follower_id FROM followers f WHERE f.created_at >= now() - $1 * interval '1 second'
+	UNION
+	SELECT u.id FROM users u WHERE u.created_at >= now() - $1 * interval '1 second'`
+
+// Compute replaces the follow suggestions of the users active within
+// activityWindow with at most perUser fresh ones and returns how many were
+// stored. Other users keep the suggestions they have until they're active
+// again.
+//
+// Candidates are the users followed by the people a user follows, users
+// posting under the same tags, and the perUser most active users of the
+// last activityWindow, so that brand new accounts get suggestions too. Each
+// mutual follow is worth 3 points, each shared tag among both users' most
+// used ones 1, and recent posts add ln(1 + n). Users already followed,
+// requested, or sharing a block with the user are left out.
+func (s *SuggestionsStore) Compute(ctx context.Context, activityWindow time.Duration, perUser int) (int64, error) {
+	query := `
+		WITH active AS (` + recentlyActiveUsers + `
+		),
+		fof AS (
+			SELECT f1.follower_id AS user_id, f2.user_id AS candidate_id, COUNT(*) AS mutuals
+			FROM followers f1
+			JOIN followers f2 ON f2.follower_id = f1.user_id
+			WHERE f1.follower_id IN (SELECT user_id FROM active)
+			GROUP BY f1.follower_id, f2.user_id
+		),
+		user_tags AS (
+			SELECT t.user_id, t.tag_id
+			FROM (
+				SELECT p.user_id, pt.tag_id,
+					row_number() OVER (PARTITION BY p.user_id ORDER BY COUNT(*) DESC, pt.tag_id) AS rank
+				FROM posts p
+				JOIN post_tags pt ON pt.post_id = p.id
+				WHERE p.deleted_at IS NULL
+				GROUP BY p.user_id, pt.tag_id
+			) t
+			WHERE t.rank <= $3
+		),
+		shared AS (
+			SELECT a.user_id, b.user_id AS candidate_id, COUNT(*) AS shared_tags
+			FROM user_tags a
+			JOIN user_tags b ON b.tag_id = a.tag_id
+			WHERE a.user_id IN (SELECT user_id FROM active)
+			GROUP BY a.user_id, b.user_id
+		),
+		activity AS (
+			SELECT p.user_id, COUNT(*) AS recent_posts
+			FROM posts p
+			WHERE p.deleted_at IS NULL AND p.created_at >= now() - $1 * interval '1 second'
+			GROUP BY p.user_id
+		),
+		popular AS (
+			SELECT a.user_id
+			FROM activity a
+			ORDER BY a.recent_posts DESC, a.user_id
+			LIMIT $2
+		),
+		candidates AS (
+			SELECT c.user_id, c.candidate_id, SUM(c.mutuals) AS mutuals, SUM(c.shared_tags) AS shared_tags
+			FROM (
+				SELECT user_id, candidate_id, mutuals, 0 AS shared_tags FROM fof
+				UNION ALL
+				SELECT user_id, candidate_id, 0, shared_tags FROM shared
+				UNION ALL
+				SELECT act.user_id, pop.user_id, 0, 0 FROM active act CROSS JOIN popular pop
+			) c
+			WHERE c.user_id <> c.candidate_id
+			GROUP BY c.user_id, c.candidate_id
+		),
+		scored AS (
+			SELECT c.user_id, c.candidate_id, c.mutuals, c.shared_tags,
+				COALESCE(a.recent_posts, 0) AS recent_posts,
+				3.0 * c.mutuals + c.shared_tags + ln(1 + COALESCE(a.recent_posts, 0)) AS score
+			FROM candidates c
+			JOIN users u ON u.id = c.candidate_id AND u.is_active
+			LEFT JOIN activity a ON a.user_id = c.candidate_id
+			WHERE NOT EXISTS (SELECT 1 FROM followers f WHERE f.user_id = c.candidate_id AND f.follower_id = c.user_id)
+				AND NOT EXISTS (SELECT 1 FROM follow_requests fr WHERE fr.user_id = c.candidate_id AND fr.requester_id = c.user_id)
+				AND NOT EXISTS (SELECT 1 FROM blocks b
+					WHERE (b.blocker_id = c.user_id AND b.blocked_id = c.candidate_id)
+						OR (b.blocker_id = c.candidate_id AND b.blocked_id = c.user_id))
+		),
+		ranked AS (
+			SELECT s.*, row_number() OVER (PARTITION BY s.user_id ORDER BY s.score DESC, s.candidate_id) AS rank
+			FROM scored s
+		)
+		INSERT INTO follow_suggestions (user_id, candidate_id, score, mutual_count, shared_tags_count, recent_posts_count)
+		SELECT user_id, candidate_id, score, mutuals, shared_tags, recent_posts
+		FROM ranked
+		WHERE rank <= $2`
+
+	var stored int64
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		stale := `DELETE FROM follow_suggestions WHERE user_id IN (` + recentlyActiveUsers + `)`
+
+		if _, err := tx.ExecContext(ctx, stale, activityWindow.Seconds()); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, query, activityWindow.Seconds(), perUser, suggestionTagsPerUser)
+		if err != nil {
+			return err
+		}
+
+		stored, err = res.RowsAffected()
+
+		return err
+	})
+
+	return stored, err
+}
+
+// GetByUserID returns userID's best suggestions. Users followed, requested
+// or blocked since the last computation are skipped.
+func (s *SuggestionsStore) GetByUserID(ctx context.Context, userID int64, limit int) ([]Suggestion, error) {
+	query := `
+		SELECT u.id, u.username, s.score, s.mutual_count, s.shared_tags_count, s.recent_posts_count
+		FROM follow_suggestions s
+		JOIN users u ON u.id = s.candidate_id
+		WHERE s.user_id = $1
+			AND NOT EXISTS (SELECT 1 FROM followers f WHERE f.user_id = s.candidate_id AND f.follower_id = $1)
+			AND NOT EXISTS (SELECT 1 FROM follow_requests fr WHERE fr.user_id = s.candidate_id AND fr.requester_id = $1)
+			AND NOT EXISTS (SELECT 1 FROM blocks b
+				WHERE (b.blocker_id = $1 AND b.blocked_id = s.candidate_id)
+					OR (b.blocker_id = s.candidate_id AND b.blocked_id = $1))
+		ORDER BY s.score DESC, s.candidate_id
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	suggestions := []Suggestion{}
+
+	for rows.Next() {
+		var suggestion Suggestion
+
+		err := rows.Scan(
+			&suggestion.User.ID,
+			&suggestion.User.Username,
+			&suggestion.Score,
+			&suggestion.MutualCount,
+			&suggestion.SharedTagsCount,
+			&suggestion.RecentPostsCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, rows.Err()
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type Tag struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	PostsCount int    `json:"posts_count"`
+}
+
+type TagsStore struct {
+	db *sql.DB
+}
+
+func (s *TagsStore) GetByName(ctx context.Context, name string) (*Tag, error) {
+	query := `
+		SELECT t.id, t.name, COUNT(pt.post_id)
+		FROM tags t
+		LEFT JOIN (post_tags pt JOIN posts p ON p.id = pt.post_id AND p.deleted_at IS NULL) ON pt.tag_id = t.id
+		WHERE t.name = $1
+		GROUP BY t.id, t.name`
+
+	tag := &Tag{}
+
+	err := s.db.QueryRowContext(ctx, query, name).Scan(&tag.ID, &tag.Name, &tag.PostsCount)
+
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return tag, nil
+}
+
+// Search returns up to limit tags starting with prefix, most used first.
+func (s *TagsStore) Search(ctx context.Context, prefix string, limit int) ([]Tag, error) {
+	query := `
+		SELECT t.id, t.name, COUNT(pt.post_id) AS posts_count
+		FROM tags t
+		LEFT JOIN (post_tags pt JOIN posts p ON p.id = pt.post_id AND p.deleted_at IS NULL) ON pt.tag_id = t.id
+		WHERE t.name LIKE $1 || '%'
+		GROUP BY t.id, t.name
+		ORDER BY posts_count DESC, t.name ASC
+		LIMIT $2`
+
+	// "_" is a valid tag character but a LIKE wildcard.
+	prefix = strings.ReplaceAll(prefix, "_", `\_`)
+
+	rows, err := s.db.QueryContext(ctx, query, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tags := []Tag{}
+
+	for rows.Next() {
+		tag := Tag{}
+
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.PostsCount); err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// GetPosts lists the posts tagged name that viewerID may see.
+func (s *TagsStore) GetPosts(ctx context.Context, name string, viewerID int64, fq PaginatedFieldQuery) ([]PostWithMetadata, error) {
+	query := `
+		SELECT p.id, p.user_id, p.title, p.content, p.content_format, p.created_at, p.version, p.tags,
+			u.username, u.email,
+			COUNT(c.id) as comments_count
+		FROM tags t
+		JOIN post_tags pt ON pt.tag_id = t.id
+		JOIN posts p ON p.id = pt.post_id
+		LEFT JOIN comments c ON p.id = c.post_id AND c.deleted_at IS NULL AND c.status = 'approved'
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE t.name = $1 AND p.deleted_at IS NULL AND ` + postVisibleTo("$4") + `
+		GROUP BY p.id, p.user_id, p.title, p.content, p.content_format, p.created_at, p.version, p.tags,
+			u.username, u.email
+		ORDER BY p.created_at ` + fq.direction() + `
+		LIMIT $2 OFFSET $3`
+
+	rows, err := s.db.QueryContext(ctx, query, name, fq.Limit, fq.Offset, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanPostsWithMetadata(rows)
+}
+
+// setPostTags makes post_tags for postID match tags, creating any tag rows
+// that don't exist yet. Tags must already be normalized.
+func setPostTags(ctx context.Context, tx *sql.Tx, postID int, tags []string) error {
+	query := `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`
+
+	if _, err := tx.ExecContext(ctx, query, pq.Array(tags)); err != nil {
+		return err
+	}
+
+	query = `DELETE FROM post_tags WHERE post_id = $1`
+
+	if _, err := tx.ExecContext(ctx, query, postID); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO post_tags (post_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)`
+
+	_, err := tx.ExecContext(ctx, query, postID, pq.Array(tags))
+
+	return err
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type Thread struct {
+	// Ancestors runs from the conversation root down to the post's parent.
+	Ancestors []Post `json:"ancestors"`
+	Post      *Post  `json:"post"`
+	// Descendants lists replies depth-first, oldest branch first.
+	Descendants []Post `json:"descendants"`
+}
+
+// GetThread returns the chain of posts postID replies to and up to limit of
+// the replies below it, leaving out the posts viewerID isn't in the audience
+// of along with the replies beneath them.
+func (s *PostsStore) GetThread(ctx context.Context, postID, viewerID int64, limit int) (*Thread, error) {
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT in_reply_to_id AS id, 1 AS depth FROM posts WHERE id = $1
+			UNION ALL
+			SELECT p.in_reply_to_id, a.depth + 1
+			FROM posts p
+			JOIN ancestors a ON p.id = a.id
+		)
+		SELECT p.id, p.user_id, p.title, p.content, p.content_format, p.created_at, p.version, p.tags,
+			p.in_reply_to_id, u.username
+		FROM ancestors a
+		JOIN posts p ON p.id = a.id
+		LEFT JOIN users u ON u.id = p.user_id
+		WHERE p.deleted_at IS NULL AND ` + postVisibleTo("$2") + `
+		ORDER BY a.depth DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	thread := &Thread{}
+
+	if thread.Ancestors, err = scanThreadPosts(rows); err != nil {
+		return nil, err
+	}
+
+	query = `
+		WITH RECURSIVE descendants AS (
+			SELECT p.id, ARRAY[p.id] AS path FROM posts p WHERE p.in_reply_to_id = $1 AND ` + postVisibleTo("$3") + `
+			UNION ALL
+			SELECT p.id, d.path || p.id
+			FROM posts p
+			JOIN descendants d ON p.in_reply_to_id = d.id
+			WHERE ` + postVisibleTo("$3") + `
+		)
+		SELECT p.id, p.user_id, p.title, p.content, p.content_format, p.created_at, p.version, p.tags,
+			p.in_reply_to_id, u.username
+		FROM descendants d
+		JOIN posts p ON p.id = d.id
+		LEFT JOIN users u ON u.id = p.user_id
+		WHERE p.deleted_at IS NULL
+		ORDER BY d.path
+		LIMIT $2`
+
+	replies, err := s.db.QueryContext(ctx, query, postID, limit, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer replies.Close()
+
+	if thread.Descendants, err = scanThreadPosts(replies); err != nil {
+		return nil, err
+	}
+
+	return thread, nil
+}
+
+func scanThreadPosts(rows *sql.Rows) ([]Post, error) {
+	posts := []Post{}
+
+	for rows.Next() {
+		post := Post{}
+
+		err := rows.Scan(
+			&post.ID,
+			&post.UserId,
+			&post.Title,
+			&post.Content,
+			&post.ContentFormat,
+			&post.CreatedAt,
+			&post.Version,
+			pq.Array(&post.Tags),
+			&post.InReplyToID,
+			&post.User.Username,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		post.User.ID = post.UserId
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GetDeletedByID returns a soft-deleted post, or ErrNotFound if the post is
+// live or gone for good.
+func (s *PostsStore) GetDeletedByID(ctx context.Context, id int) (*Post, error) {
+	query := `
+		SELECT id, title, content, content_format, user_id, tags, created_at, updated_at, version,
+			quote_of_id, in_reply_to_id, deleted_at
+		FROM posts
+		WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	post := &Post{}
+
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&post.ID,
+		&post.Title,
+		&post.Content,
+		&post.ContentFormat,
+		&post.UserId,
+		pq.Array(&post.Tags),
+		&post.CreatedAt,
+		&post.UpdatedAt,
+		&post.Version,
+		&post.QuoteOfID,
+		&post.InReplyToID,
+		&post.DeletedAt,
+	)
+
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return post, nil
+}
+
+func (s *PostsStore) Restore(ctx context.Context, id int) error {
+	query := `UPDATE posts SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetDeletedByUserID lists userID's posts waiting in the trash, most
+// recently deleted first.
+func (s *PostsStore) GetDeletedByUserID(ctx context.Context, userID int64, fq PaginatedFieldQuery) ([]Post, error) {
+	query := `
+		SELECT id, title, content, content_format, user_id, tags, created_at, updated_at, version, deleted_at
+		FROM posts
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	posts := []Post{}
+
+	for rows.Next() {
+		post := Post{}
+
+		err := rows.Scan(
+			&post.ID,
+			&post.Title,
+			&post.Content,
+			&post.ContentFormat,
+			&post.UserId,
+			pq.Array(&post.Tags),
+			&post.CreatedAt,
+			&post.UpdatedAt,
+			&post.Version,
+			&post.DeletedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// Purge permanently removes posts deleted before the cutoff along with
+// their comments, which have no foreign key to cascade from.
+func (s *PostsStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			DELETE FROM comments
+			WHERE post_id IN (SELECT id FROM posts WHERE deleted_at < $1)`
+
+		if _, err := tx.ExecContext(ctx, query, before); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM posts WHERE deleted_at < $1`, before)
+		if err != nil {
+			return err
+		}
+
+		purged, err = res.RowsAffected()
+
+		return err
+	})
+
+	return purged, err
+}
+
+// Delete soft-deletes a comment.
+func (s *CommentsStore) Delete(ctx context.Context, id int) error {
+	query := `UPDATE comments SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetDeletedByID returns a soft-deleted comment whose post is still live.
+func (s *CommentsStore) GetDeletedByID(ctx context.Context, id int) (*Comment, error) {
+	query := `
+		SELECT c.id, c.post_id, c.user_id, c.content, c.created_at, c.deleted_at
+		FROM comments c
+		JOIN posts p ON p.id = c.post_id AND p.deleted_at IS NULL
+		WHERE c.id = $1 AND c.deleted_at IS NOT NULL`
+
+	comment := &Comment{}
+
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&comment.ID,
+		&comment.PostID,
+		&comment.UserID,
+		&comment.Content,
+		&comment.CreatedAt,
+		&comment.DeletedAt,
+	)
+
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return comment, nil
+}
+
+// Restore brings a comment back, as long as its post hasn't been deleted.
+func (s *CommentsStore) Restore(ctx context.Context, id int) error {
+	query := `
+		UPDATE comments c
+		SET deleted_at = NULL
+		FROM posts p
+		WHERE c.id = $1 AND c.deleted_at IS NOT NULL AND p.id = c.post_id AND p.deleted_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetDeletedByUserID lists userID's deleted comments, most recently deleted
+// first.
+func (s *CommentsStore) GetDeletedByUserID(ctx context.Context, userID int64, fq PaginatedFieldQuery) ([]Comment, error) {
+	query := `
+		SELECT id, post_id, user_id, content, created_at, deleted_at
+		FROM comments
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	comments := []Comment{}
+
+	for rows.Next() {
+		comment := Comment{}
+
+		err := rows.Scan(
+			&comment.ID,
+			&comment.PostID,
+			&comment.UserID,
+			&comment.Content,
+			&comment.CreatedAt,
+			&comment.DeletedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// Purge permanently removes comments deleted before the cutoff.
+func (s *CommentsStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	// Comments with replies stay as placeholders; deleted chains are
+	// purged from the leaves up over successive runs.
+	query := `
+		DELETE FROM comments c
+		WHERE c.deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = c.id)`
+
+	res, err := s.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
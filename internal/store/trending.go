@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type TrendingTag struct {
+	Name       string  `json:"name"`
+	Score      float64 `json:"score"`
+	PostsCount int     `json:"posts_count"`
+}
+
+type TrendingPost struct {
+	PostWithMetadata
+
+	Score float64 `json:"score"`
+}
+
+type TrendingStore struct {
+	db *sql.DB
+}
+
+// trendingScoresCTE scores every public post with activity inside the
+// window ($1, in seconds), leaving out posts of private accounts. Each event
+// is weighted and decays exponentially with its age using a half-life of $2
+// seconds, so recent activity dominates.
+const trendingScoresCTE = `
+	WITH activity AS (
+		SELECT p.id AS post_id, p.created_at AS at, 1.0 AS weight
+		FROM posts p
+		WHERE p.created_at >= now() - $1 * interval '1 second'
+		UNION ALL
+		SELECT c.post_id, c.created_at, 2.0
+		FROM comments c
+		WHERE c.created_at >= now() - $1 * interval '1 second' AND c.deleted_at IS NULL AND c.status = 'approved'
+		UNION ALL
+		SELECT pr.post_id, pr.created_at, 1.5
+		FROM post_reactions pr
+		WHERE pr.created_at >= now() - $1 * interval '1 second'
+	),
+	scores AS (
+		SELECT a.post_id, SUM(a.weight * exp(-ln(2) * extract(epoch FROM now() - a.at) / $2)) AS score
+		FROM activity a
+		JOIN posts p ON p.id = a.post_id AND p.deleted_at IS NULL AND p.audience = 'public'
+			AND NOT EXISTS (SELECT 1 FROM users pu WHERE pu.id = p.user_id AND pu.is_private)
+		GROUP BY a.post_id
+	)`
+
+// trendingPostColumns selects a post with its metadata; it expects posts
+// aliased as p and users as u.
+const trendingPostColumns = `
+	p.id, p.user_id, p.title, p.content, p.content_format, p.created_at, p.version, p.tags,
+	u.username, u.email,
+	(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL AND c.status = 'approved') AS comments_count`
+
+// halfLife is how fast activity decays inside a window.
+func halfLife(window time.Duration) float64 {
+	return (window / 4).Seconds()
+}
+
+func (s *TrendingStore) ComputeTags(ctx context.Context, window time.Duration, limit int) ([]TrendingTag, error) {
+	query := trendingScoresCTE + `
+		SELECT t.name, SUM(s.score) AS score, COUNT(*) AS posts_count
+		FROM scores s
+		JOIN post_tags pt ON pt.post_id = s.post_id
+		JOIN tags t ON t.id = pt.tag_id
+		GROUP BY t.name
+		ORDER BY score DESC, t.name ASC
+		LIMIT $3`
+
+	rows, err := s.db.QueryContext(ctx, query, window.Seconds(), halfLife(window), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanTrendingTags(rows)
+}
+
+func (s *TrendingStore) ComputePosts(ctx context.Context, window time.Duration, limit int) ([]TrendingPost, error) {
+	query := trendingScoresCTE + `
+		SELECT ` + trendingPostColumns + `, s.score
+		FROM scores s
+		JOIN posts p ON p.id = s.post_id
+		LEFT JOIN users u ON u.id = p.user_id
+		ORDER BY s.score DESC, p.id DESC
+		LIMIT $3`
+
+	rows, err := s.db.QueryContext(ctx, query, window.Seconds(), halfLife(window), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanTrendingPosts(rows)
+}
+
+// SaveSnapshot replaces the stored ranking for a window.
+func (s *TrendingStore) SaveSnapshot(ctx context.Context, window string, tags []TrendingTag, posts []TrendingPost) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM trending_tags WHERE time_window = $1`, window); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM trending_posts WHERE time_window = $1`, window); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO trending_tags (time_window, rank, name, score, posts_count) VALUES ($1, $2, $3, $4, $5)`
+
+		for i, t := range tags {
+			if _, err := tx.ExecContext(ctx, query, window, i+1, t.Name, t.Score, t.PostsCount); err != nil {
+				return err
+			}
+		}
+
+		query = `INSERT INTO trending_posts (time_window, rank, post_id, score) VALUES ($1, $2, $3, $4)`
+
+		for i, p := range posts {
+			if _, err := tx.ExecContext(ctx, query, window, i+1, p.ID, p.Score); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *TrendingStore) GetTags(ctx context.Context, window string, limit int) ([]TrendingTag, error) {
+	query := `
+		SELECT name, score, posts_count
+		FROM trending_tags
+		WHERE time_window = $1
+		ORDER BY rank
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, window, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanTrendingTags(rows)
+}
+
+func (s *TrendingStore) GetPosts(ctx context.Context, window string, limit int) ([]TrendingPost, error) {
+	query := `
+		SELECT ` + trendingPostColumns + `, tp.score
+		FROM trending_posts tp
+		JOIN posts p ON p.id = tp.post_id AND p.deleted_at IS NULL
+		LEFT JOIN users u ON u.id = p.user_id
+		WHERE tp.time_window = $1
+		ORDER BY tp.rank
+		LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, window, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanTrendingPosts(rows)
+}
+
+func scanTrendingTags(rows *sql.Rows) ([]TrendingTag, error) {
+	tags := []TrendingTag{}
+
+	for rows.Next() {
+		tag := TrendingTag{}
+
+		if err := rows.Scan(&tag.Name, &tag.Score, &tag.PostsCount); err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func scanTrendingPosts(rows *sql.Rows) ([]TrendingPost, error) {
+	posts := []TrendingPost{}
+
+	for rows.Next() {
+		post := TrendingPost{}
+
+		err := rows.Scan(
+			&post.ID,
+			&post.UserId,
+			&post.Title,
+			&post.Content,
+			&post.ContentFormat,
+			&post.CreatedAt,
+			&post.Version,
+			pq.Array(&post.Tags),
+			&post.User.Username,
+			&post.User.Email,
+			&post.CommentsCount,
+			&post.Score,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
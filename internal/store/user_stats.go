@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+// UserStats holds a user's profile counters, kept up to date by triggers on
+// the followers and posts tables, and their relationship to the viewer.
+type UserStats struct {
+	FollowersCount int64 `json:"followers_count"`
+	FollowingCount int64 `json:"following_count"`
+	PostsCount     int64 `json:"posts_count"`
+	IsFollowing    bool  `json:"is_following"`
+	FollowsYou     bool  `json:"follows_you"`
+}
+
+// GetStats returns userID's counters as seen by viewerID.
+func (s *UsersStore) GetStats(ctx context.Context, userID, viewerID int64) (*UserStats, error) {
+	query := `
+		SELECT COALESCE(s.followers_count, 0), COALESCE(s.following_count, 0), COALESCE(s.posts_count, 0),
+			EXISTS (SELECT 1 FROM followers f WHERE f.user_id = u.id AND f.follower_id = $2),
+			EXISTS (SELECT 1 FROM followers f WHERE f.user_id = $2 AND f.follower_id = u.id)
+		FROM users u
+		LEFT JOIN user_stats s ON s.user_id = u.id
+		WHERE u.id = $1`
+
+	stats := &UserStats{}
+
+	err := s.db.QueryRowContext(ctx, query, userID, viewerID).Scan(
+		&stats.FollowersCount,
+		&stats.FollowingCount,
+		&stats.PostsCount,
+		&stats.IsFollowing,
+		&stats.FollowsYou,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
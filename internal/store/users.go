@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"log"
 	"time"
 
@@ -18,6 +19,7 @@ type User struct {
 	Password  Password `json:"-"`
 	CreatedAt string   `json:"created_at"`
 	IsActive  bool     `json:"is_active"`
+	IsPrivate bool     `json:"is_private"`
 	RoleID    int64    `json:"role_id"`
 	Role 	Role     `json:"role"`
 }
@@ -81,7 +83,7 @@ func (s *UsersStore) Create(ctx context.Context, tx *sql.Tx, user *User) error {
 func (s *UsersStore) GetById(ctx context.Context, id int) (*User, error) {
 
 	query := `
-	SELECT users.id, username, email,password, created_at, roles.*
+	SELECT users.id, username, email,password, created_at, is_private, roles.*
 	FROM users 
 	JOIN roles ON (users.role_id = roles.id)
 	WHERE users.id = $1`
@@ -100,6 +102,7 @@ func (s *UsersStore) GetById(ctx context.Context, id int) (*User, error) {
 		&user.Email,
 		&user.Password.hash,
 		&user.CreatedAt,
+		&user.IsPrivate,
 		&user.Role.ID,
 		&user.Role.Name,
 		&user.Role.Level,
@@ -119,6 +122,78 @@ func (s *UsersStore) GetById(ctx context.Context, id int) (*User, error) {
 
 }
 
+// SetPrivate changes whether userID's account is private. Making an account
+// public approves every pending follow request; it returns the requesters
+// who now follow userID.
+func (s *UsersStore) SetPrivate(ctx context.Context, userID int64, private bool) ([]int64, error) {
+	followers := []int64{}
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE users SET is_private = $1 WHERE id = $2`, private, userID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		if private {
+			return nil
+		}
+
+		query := `DELETE FROM follow_requests WHERE user_id = $1 RETURNING requester_id`
+
+		requests, err := tx.QueryContext(ctx, query, userID)
+		if err != nil {
+			return err
+		}
+
+		requesters := []int64{}
+
+		for requests.Next() {
+			var id int64
+
+			if err := requests.Scan(&id); err != nil {
+				requests.Close()
+				return err
+			}
+
+			requesters = append(requesters, id)
+		}
+
+		requests.Close()
+
+		if err := requests.Err(); err != nil {
+			return err
+		}
+
+		for _, requesterID := range requesters {
+			err := insertFollow(ctx, tx, requesterID, userID)
+			switch {
+			case err == nil:
+				followers = append(followers, requesterID)
+			case errors.Is(err, ErrAlreadyFollowing):
+			default:
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return followers, nil
+}
+
 func (s *UsersStore) CreateAndInvite(ctx context.Context, user *User, token string, invitationExpiry time.Duration) error {
 
 	//transaction wrapper